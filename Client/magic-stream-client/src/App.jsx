@@ -18,7 +18,7 @@ import { useNavigate } from "react-router-dom";
 // creates and exports App function that renders all app components
 function App() {
   // uses custom useAuth hook to manage authentication
-  const { auth, setAuth } = useAuth("");
+  const { setAuth } = useAuth("");
   // uses usenNavigate hook to navigate between pages
   const navigate = useNavigate();
 
@@ -26,9 +26,8 @@ function App() {
   const handleLogout = async () => {
     // try catch block to handle exceptions while making post request to logout user
     try {
-      const response = await axiosClient.post("/logout", {
-        user_id: auth.user_id,
-      });
+      // server takes user and session from cookies
+      const response = await axiosClient.post("/logout", {});
       console.log(response.data);
       // sets auth state to null
       setAuth(null);
@@ -1,6 +1,7 @@
 // imports libraries(components)
 import axiosClient from "../../api/axiosConfig";
 import Movies from "../movies/Movies";
+import Button from "react-bootstrap/Button";
 // imports hooks
 import { useState, useEffect } from "react";
 
@@ -13,8 +14,11 @@ const Home = ({ updateMovieReview }) => {
   const [loading, setLoading] = useState(false);
   // // uses useState hook to manage error message state
   const [message, setMessage] = useState("");
+  // // uses useState hook to manage current page and number of pages of movies list
+  const [page, setPage] = useState(1);
+  const [totalPages, setTotalPages] = useState(1);
 
-  // uses useEffect hook to fetch movies data from database when the component mounts
+  // uses useEffect hook to fetch page of movies from database when the component mounts or page changes
   useEffect(() => {
     // defines async function to fetch movies
     const fetchMovies = async () => {
@@ -22,9 +26,12 @@ const Home = ({ updateMovieReview }) => {
       setMessage("");
       // try catch block to handle errors while making get request to database
       try {
-        const response = await axiosClient.get("/movies");
-        setMovies(response.data);
-        if (response.data.length === 0) {
+        const response = await axiosClient.get("/movies", {
+          params: { page },
+        });
+        setMovies(response.data.movies);
+        setTotalPages(response.data.total_pages || 1);
+        if (response.data.movies.length === 0) {
           setMessage("There are currently no movies available");
         }
       } catch (error) {
@@ -37,7 +44,7 @@ const Home = ({ updateMovieReview }) => {
     };
     // calls fetchMovies function
     fetchMovies();
-  }, []);
+  }, [page]);
 
   return (
     <>
@@ -51,6 +58,28 @@ const Home = ({ updateMovieReview }) => {
           updateMovieReview={updateMovieReview}
         />
       )}
+      {/* Displays buttons to move between pages when there is more than one page */}
+      {totalPages > 1 && (
+        <div className="d-flex justify-content-center align-items-center gap-3 my-4">
+          <Button
+            variant="outline-info"
+            disabled={loading || page <= 1}
+            onClick={() => setPage(page - 1)}
+          >
+            Previous
+          </Button>
+          <span>
+            Page {page} of {totalPages}
+          </span>
+          <Button
+            variant="outline-info"
+            disabled={loading || page >= totalPages}
+            onClick={() => setPage(page + 1)}
+          >
+            Next
+          </Button>
+        </div>
+      )}
     </>
   );
 };
@@ -53,6 +53,11 @@ const Login = () => {
       // navigate('/');
     } catch (err) {
       console.error(err);
+      // shows server message when login is locked after too many failed attempts
+      if (err.response?.status === 429) {
+        setError(err.response.data.error);
+        return;
+      }
       setError("Invalid email or password");
     } finally {
       setLoading(false);
@@ -53,13 +53,21 @@ const Review = () => {
       });
       console.log(response.data);
 
+      // review is ranked in background, polls review job until ranking is ready
+      let job = { status: response.data?.review_status };
+      for (let attempt = 0; attempt < 30 && job.status === "pending"; attempt++) {
+        await new Promise((resolve) => setTimeout(resolve, 2000));
+        const jobResponse = await axiosPrivate.get(response.data.status_url);
+        job = jobResponse.data;
+      }
+
       // uses setMovie hook to manage new state of movie with updated review
       setMovie(() => ({
         ...movie,
         admin_review: response.data?.admin_review ?? movie.admin_review,
         ranking: {
           ranking_name:
-            response.data?.ranking_name ?? movie.ranking?.ranking_name,
+            job.ranking?.ranking_name ?? movie.ranking?.ranking_name,
         },
       }));
     } catch (err) {
@@ -0,0 +1,65 @@
+// marks file as part of main package
+package main
+
+// imports packages
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// creates main function that generates private key used to sign tokens, to rotate keys generate new key, point JWT_SIGNING_KEY_ID at it
+// and remove old key once tokens it signed have expired
+func main() {
+	// defines command line flags
+	dir := flag.String("dir", "keys", "directory of signing keys")
+	keyType := flag.String("type", "ed25519", "key type: ed25519 or rsa")
+	bits := flag.Int("bits", 3072, "size of rsa key in bits")
+	kid := flag.String("kid", "key-"+time.Now().UTC().Format("20060102150405"), "key id, used as file name")
+	flag.Parse()
+
+	// generates key
+	var key any
+	var err error
+	switch *keyType {
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, *bits)
+	default:
+		log.Fatalf("unknown key type %q", *keyType)
+	}
+	if err != nil {
+		log.Fatalf("Failed to generate key: %v", err)
+	}
+
+	// encodes key as pkcs8 pem
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		log.Fatalf("Failed to encode key: %v", err)
+	}
+
+	// writes key file that only owner can read
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatalf("Failed to create key directory: %v", err)
+	}
+	path := filepath.Join(*dir, *kid+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatalf("Failed to create key file: %v", err)
+	}
+	defer file.Close()
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		log.Fatalf("Failed to write key file: %v", err)
+	}
+
+	fmt.Printf("Wrote %s key %q to %s\n", *keyType, *kid, path)
+}
@@ -0,0 +1,165 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines part of movie after which movie counts as watched
+const completedProgressRatio = 0.9
+
+// creates function that handles post request to /movie/:imdb_id/progress endpoint to save playback position of user
+func SaveProgress(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets movie id from url parameter
+		movieId := c.Param("imdb_id")
+
+		// defines progress request struct
+		var req struct {
+			PositionSeconds float64 `json:"position_seconds" validate:"gte=0"`
+			DurationSeconds float64 `json:"duration_seconds" validate:"gt=0"`
+		}
+		// uses ShouldBindJSON function to bind json request body to progress request struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate progress
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+		// position can not be after end of movie
+		req.PositionSeconds = min(req.PositionSeconds, req.DurationSeconds)
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// checks if movie exists
+		count, err := database.OpenCollection("movies", client).CountDocuments(ctx, bson.M{"imdb_id": movieId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movie from database"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		// defines update, movie stays completed once user has watched it to the end
+		now := time.Now()
+		set := bson.M{
+			"position_seconds": req.PositionSeconds,
+			"duration_seconds": req.DurationSeconds,
+			"last_watched_at":  now,
+		}
+		update := bson.M{"$set": set, "$setOnInsert": bson.M{"completed": false}}
+		if req.PositionSeconds >= req.DurationSeconds*completedProgressRatio {
+			set["completed"] = true
+			set["completed_at"] = now
+			update = bson.M{"$set": set}
+		}
+
+		// saves progress
+		var progress models.ViewingProgress
+		err = database.OpenCollection("viewing_history", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": userId, "imdb_id": movieId},
+			update,
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&progress)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while saving progress"})
+			return
+		}
+
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+// creates function that handles get request to /history endpoint to get recently watched movies with resume points
+func GetHistory(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// reads pagination from query parameters
+		pagination, err := utils.GetPagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// opens viewing history collection from database
+		var historyCollection *mongo.Collection = database.OpenCollection("viewing_history", client)
+
+		// counts watched movies
+		totalCount, err := historyCollection.CountDocuments(ctx, bson.M{"user_id": userId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while counting watched movies"})
+			return
+		}
+
+		// defines pipeline that gets page of recently watched movies and joins movie documents
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"user_id": userId}}},
+			{{Key: "$sort", Value: bson.D{{Key: "last_watched_at", Value: -1}, {Key: "_id", Value: -1}}}},
+			{{Key: "$skip", Value: pagination.Skip()}},
+			{{Key: "$limit", Value: pagination.Limit}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":         "movies",
+				"localField":   "imdb_id",
+				"foreignField": "imdb_id",
+				"as":           "movie",
+			}}},
+			{{Key: "$unwind", Value: "$movie"}},
+		}
+
+		// defines cursor
+		cursor, err := historyCollection.Aggregate(ctx, pipeline)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching history from database"})
+			return
+		}
+		// closes cursor after function ends(in any case)
+		defer cursor.Close(ctx)
+
+		// passes cursor to items variable
+		items := []models.HistoryEntry{}
+		if err := cursor.All(ctx, &items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode history from database"})
+			return
+		}
+
+		// uses context to write json response with history page
+		c.JSON(http.StatusOK, models.HistoryPage{
+			Items:        items,
+			PageMetadata: utils.BuildPageMetadata(c, pagination, totalCount),
+		})
+	}
+}
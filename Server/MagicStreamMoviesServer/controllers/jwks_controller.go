@@ -0,0 +1,19 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// creates function that handles get request to /.well-known/jwks.json endpoint to publish public keys that verify tokens
+func GetJWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// lets clients cache keys for a while, new keys are published before they sign tokens
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, utils.GetJWKS())
+	}
+}
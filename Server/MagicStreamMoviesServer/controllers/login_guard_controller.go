@@ -0,0 +1,53 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// creates function that handles post request to /admin/login-unlock endpoint, admin unlocks account locked after failed logins
+// and/or client ip
+func UnlockLogin(guard *loginguard.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// binds request body to unlock struct
+		var req models.LoginUnlock
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+		if req.Email == "" && req.IP == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email or ip is required"})
+			return
+		}
+
+		// gets id of admin, it is stored in audit event
+		adminId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// uses context to cancel request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		if err := guard.Unlock(ctx, req.Email, req.IP, adminId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error unlocking login"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Login unlocked", "email": req.Email, "ip": req.IP})
+	}
+}
@@ -15,10 +15,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
-	"github.com/tmc/langchaingo/llms/openai"
 
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/jobs"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/recommender"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -28,9 +29,104 @@ import (
 // defines validator
 var validate = validator.New()
 
-// creates function that gets movies data(collection) from database, marks function as gin handler in order to be able to handle requests
+// defines sort options allowed for movies listing
+var movieSortFields = map[string]bson.D{
+	"title":    {{Key: "title", Value: 1}},
+	"-title":   {{Key: "title", Value: -1}},
+	"ranking":  {{Key: "ranking.ranking_value", Value: 1}},
+	"-ranking": {{Key: "ranking.ranking_value", Value: -1}},
+	// sorts movies by bayesian weighted audience score or by number of audience ratings
+	"audience_score":  {{Key: "audience_score.weighted_score", Value: 1}},
+	"-audience_score": {{Key: "audience_score.weighted_score", Value: -1}},
+	"rating_count":    {{Key: "audience_score.count", Value: 1}},
+	"-rating_count":   {{Key: "audience_score.count", Value: -1}},
+}
+
+// creates function that builds movies filter from genre, ranking_name, min_ranking and max_ranking query parameters
+func BuildMovieFilter(c *gin.Context) (bson.M, error) {
+	// defines empty filter
+	filter := bson.M{}
+
+	// adds genre filter, genres can be passed as repeated or comma separated parameters
+	var genres []string
+	for _, value := range c.QueryArray("genre") {
+		for _, genre := range strings.Split(value, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				genres = append(genres, genre)
+			}
+		}
+	}
+	if len(genres) > 0 {
+		filter["genre.genre_name"] = bson.M{"$in": genres}
+	}
+
+	// adds ranking name filter
+	if rankingName := strings.TrimSpace(c.Query("ranking_name")); rankingName != "" {
+		filter["ranking.ranking_name"] = rankingName
+	}
+
+	// adds ranking value range filter
+	rankingRange := bson.M{}
+	if minStr := c.Query("min_ranking"); minStr != "" {
+		minRanking, err := strconv.Atoi(minStr)
+		if err != nil {
+			return nil, errors.New("min_ranking must be an integer")
+		}
+		rankingRange["$gte"] = minRanking
+	}
+	if maxStr := c.Query("max_ranking"); maxStr != "" {
+		maxRanking, err := strconv.Atoi(maxStr)
+		if err != nil {
+			return nil, errors.New("max_ranking must be an integer")
+		}
+		rankingRange["$lte"] = maxRanking
+	}
+	if len(rankingRange) > 0 {
+		filter["ranking.ranking_value"] = rankingRange
+	}
+
+	return filter, nil
+}
+
+// creates function that builds sort order of movies from sort query parameter
+func BuildMovieSort(c *gin.Context) (bson.D, error) {
+	// defines sort parameter, sorts movies by title by default
+	sortParam := c.DefaultQuery("sort", "title")
+
+	// checks if sort parameter is allowed
+	sort, ok := movieSortFields[sortParam]
+	if !ok {
+		return nil, errors.New("sort must be one of title, -title, ranking, -ranking, audience_score, -audience_score, rating_count, -rating_count")
+	}
+
+	// adds _id to sort order to keep pages stable
+	return append(append(bson.D{}, sort...), bson.E{Key: "_id", Value: 1}), nil
+}
+
+// creates function that gets paginated, filtered and sorted movies data from database, marks function as gin handler in order to be able to handle requests
 func GetMovies(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// reads pagination from query parameters
+		pagination, err := utils.GetPagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// builds filter from query parameters
+		filter, err := BuildMovieFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// builds sort order from query parameters
+		sort, err := BuildMovieSort(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		// creates special context that cancels request if timeout occurs - when function ends(after 100 seconds)
 		ctx, cancel := context.WithTimeout(c, 100*time.Second)
 		defer cancel()
@@ -38,10 +134,20 @@ func GetMovies(client *mongo.Client) gin.HandlerFunc {
 		// uses OpenCollection function from database package to open movies collection from database
 		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 
+		// counts all movies that match filter
+		totalCount, err := movieCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while counting movies in database"})
+			return
+		}
+
+		// defines find options with sort order, skip and limit of current page
+		findOptions := options.Find().SetSort(sort).SetSkip(pagination.Skip()).SetLimit(pagination.Limit)
+
 		// gets movies data from database
-		var movies []models.Movie
+		movies := []models.Movie{}
 		// creates cursor
-		cursor, err := movieCollection.Find(ctx, bson.M{})
+		cursor, err := movieCollection.Find(ctx, filter, findOptions)
 
 		// checks if error occurs
 		if err != nil {
@@ -58,8 +164,11 @@ func GetMovies(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		// uses context to write json response with movies data
-		c.JSON(http.StatusOK, movies)
+		// uses context to write json response with movies data and page metadata
+		c.JSON(http.StatusOK, models.MoviePage{
+			Movies:       movies,
+			PageMetadata: utils.BuildPageMetadata(c, pagination, totalCount),
+		})
 	}
 }
 
@@ -72,7 +181,7 @@ func GetMovie(client *mongo.Client) gin.HandlerFunc {
 		defer cancel()
 
 		// gets movie id from url parameter
-		movieID := c.Param("movie_id")
+		movieID := c.Param("imdb_id")
 		// if movie id is empty, uses context to write json response with error message
 		if movieID == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Movie ID is required"})
@@ -95,8 +204,8 @@ func GetMovie(client *mongo.Client) gin.HandlerFunc {
 	}
 }
 
-// creates function that handles post request to /add-movie endpoint to add new movie to a database
-func AddMovie(client *mongo.Client) gin.HandlerFunc {
+// creates function that handles post request to /add-movie endpoint to add new movie to a database, embedding of movie is built in background
+func AddMovie(client *mongo.Client, embeddings *jobs.EmbeddingSync) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// creates special context that cancels request if timeout occurs - when function ends(after 100 seconds) - to prevent memory leaks
 		ctx, cancel := context.WithTimeout(c, 100*time.Second)
@@ -119,36 +228,201 @@ func AddMovie(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
+		// resets fields maintained by server, new movie has no audience ratings yet, so its weighted score is prior mean
+		priorMean, _ := utils.RatingPrior()
+		movie.AudienceScore = models.AudienceScore{WeightedScore: priorMean}
+		movie.ReviewStatus = ""
+		movie.ReviewJobID = ""
+
 		// defines movieCollection that is type of mongo collection defined in database
 		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 
 		// uses InsertOne function to add movie data to database
 		result, err := movieCollection.InsertOne(ctx, movie)
+		// if movie with the same imdb id already exists, uses context to write json response with id of existing movie
+		if mongo.IsDuplicateKeyError(err) {
+			var existing models.Movie
+			if err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movie.ImdbID}).Decode(&existing); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching existing movie from database"})
+				return
+			}
+			c.JSON(http.StatusConflict, gin.H{"error": "Movie with this imdb_id already exists", "_id": existing.ID})
+			return
+		}
 		// if error occurs, uses context to write json response with error message
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while adding movie to database"})
 			return
 		}
 
+		// asks for embedding of new movie
+		embeddings.Notify()
+
 		// uses context to write json response with movie data
 		c.JSON(http.StatusCreated, result)
 	}
 }
 
-// creates function that updates  movie review as admin
-func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
+// creates function that handles put request to /movie/:imdb_id endpoint to replace movie data as admin
+func UpdateMovie(client *mongo.Client, embeddings *jobs.EmbeddingSync) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// defines movie id from context parameter
+		movieId := c.Param("imdb_id")
+
+		// defines movie variable to store incoming client movie data on server
+		var movie models.Movie
+		// uses ShouldBindJSON function to bind json request body to movie struct
+		if err := c.ShouldBindJSON(&movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// keeps movie id from url, movie id can not be changed
+		movie.ImdbID = movieId
+
+		// saves movie data
+		replaceMovie(c, client, embeddings, movie)
+	}
+}
+
+// creates function that handles patch request to /movie/:imdb_id endpoint to update some of movie fields as admin
+func PatchMovie(client *mongo.Client, embeddings *jobs.EmbeddingSync) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// defines movie id from context parameter
+		movieId := c.Param("imdb_id")
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// gets existing movie data from database
+		var movie models.Movie
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+		if err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movieId}).Decode(&movie); err != nil {
+			// if movie is not found, uses context to write json response with not found status
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movie from database"})
+			return
+		}
+
+		// binds json request body on top of existing movie data, fields missing in request body keep their values
+		existingID := movie.ID
+		if err := c.ShouldBindJSON(&movie); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+
+		// keeps ids of existing movie, they can not be changed
+		movie.ID = existingID
+		movie.ImdbID = movieId
+
+		// saves movie data
+		replaceMovie(c, client, embeddings, movie)
+	}
+}
+
+// creates function that validates movie and replaces fields that admin can edit in movie document with the same imdb id, writes json response
+// fields maintained by server, such as audience score and review status, keep their values, embedding is rebuilt in background
+func replaceMovie(c *gin.Context, client *mongo.Client, embeddings *jobs.EmbeddingSync, movie models.Movie) {
+	// uses validator to validate movie data
+	if err := validate.Struct(movie); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	// creates special context that cancels request if timeout occurs
+	ctx, cancel := context.WithTimeout(c, 100*time.Second)
+	defer cancel()
+
+	// defines movieCollection that is type of mongo collection defined in database
+	var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+	// defines update of editable movie fields
+	update := bson.M{"$set": bson.M{
+		"title":        movie.Title,
+		"poster_path":  movie.PosterPath,
+		"youtube_id":   movie.YouTubeID,
+		"genre":        movie.Genre,
+		"admin_review": movie.AdminReview,
+		"ranking":      movie.Ranking,
+	}}
+
+	// updates movie and reads updated document
+	var updated models.Movie
+	err := movieCollection.FindOneAndUpdate(ctx, bson.M{"imdb_id": movie.ImdbID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		// if movie is not found, uses context to write json response with not found status
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while updating movie in database"})
+		return
+	}
+
+	// asks for new embedding, sync skips movie when embedded text has not changed
+	embeddings.Notify()
+
+	// uses context to write json response with updated movie data
+	c.JSON(http.StatusOK, updated)
+}
+
+// creates function that handles delete request to /movie/:imdb_id endpoint to remove movie as admin
+func DeleteMovie(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// uses GetRoleFromContext function to get user role from context
-		role, err := utils.GetRoleFromContext(c)
+		// defines movie id from context parameter
+		movieId := c.Param("imdb_id")
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// uses DeleteOne function to remove movie from database
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+		result, err := movieCollection.DeleteOne(ctx, bson.M{"imdb_id": movieId})
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Role not found in context"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while deleting movie from database"})
 			return
 		}
-		// if role is not admin, uses context to write json response with error message
-		if role != "ADMIN" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User must be part of the ADMIN role"})
+		// if movie is not found, uses context to write json response with not found status
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 			return
 		}
+
+		// removes deleted movie from watchlists of all users
+		if _, err := database.OpenCollection("watchlists", client).DeleteMany(ctx, bson.M{"imdb_id": movieId}); err != nil {
+			log.Println("Warning: unable to remove deleted movie from watchlists:", err)
+		}
+		// removes ratings of deleted movie
+		if _, err := database.OpenCollection("ratings", client).DeleteMany(ctx, bson.M{"imdb_id": movieId}); err != nil {
+			log.Println("Warning: unable to remove ratings of deleted movie:", err)
+		}
+		// removes user reviews of deleted movie
+		if _, err := database.OpenCollection("reviews", client).DeleteMany(ctx, bson.M{"imdb_id": movieId}); err != nil {
+			log.Println("Warning: unable to remove reviews of deleted movie:", err)
+		}
+		// removes deleted movie from viewing history of all users
+		if _, err := database.OpenCollection("viewing_history", client).DeleteMany(ctx, bson.M{"imdb_id": movieId}); err != nil {
+			log.Println("Warning: unable to remove deleted movie from viewing history:", err)
+		}
+		// removes embedding of deleted movie
+		if _, err := database.OpenCollection("movie_embeddings", client).DeleteOne(ctx, bson.M{"imdb_id": movieId}); err != nil {
+			log.Println("Warning: unable to remove embedding of deleted movie:", err)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Movie deleted successfully"})
+	}
+}
+
+// creates function that updates  movie review as admin, route must be protected with RequireRole("ADMIN")
+// review is saved immediately with pending status and is ranked in background by review queue
+func AdminReviewUpdate(client *mongo.Client, queue *jobs.ReviewQueue, embeddings *jobs.EmbeddingSync) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		// defines MovieId from context parameter
 		movieId := c.Param("imdb_id")
 		// if movie id is empty, uses context to write json response with error message
@@ -162,12 +436,6 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 			AdminReview string `json:"admin_review"`
 		}
 
-		// defines response struct
-		var resp struct {
-			RankingName string `json:"ranking_name"`
-			AdminReview string `json:"admin_review"`
-		}
-
 		// uses ShouldBind function to bind json request body to update review request struct
 		if err := c.ShouldBind(&req); err != nil {
 			// if error occurs, uses context to write json response with error message
@@ -175,35 +443,27 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		// calls GetReviewRankings function to return sentiment and rank value according to admin review input
-		sentiment, rankVal, err := GetReviewRanking(req.AdminReview, client, c)
-		if err != nil {
-			// uses context to write json response with error message
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while getting review rankings"})
-			return
-		}
+		// uses context to clean resources after performing updates in database
+		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 
-		// defines filter based on movie id
-		filter := bson.M{"imdb_id": movieId}
+		// defines background job that ranks review, admin who wrote review can follow it
+		adminId, _ := utils.GetUserIdFromContext(c)
+		job := jobs.NewReviewJob(models.ReviewKindAdmin, movieId, req.AdminReview, adminId)
 
-		// defines update that will update fields in database
+		// defines update that saves review with pending ranking status
 		update := bson.M{
 			"$set": bson.M{
-				"admin_review": req.AdminReview,
-				"ranking": bson.M{
-					"ranking_value": rankVal,
-					"ranking_name":  sentiment,
-				},
+				"admin_review":  req.AdminReview,
+				"review_status": models.ReviewStatusPending,
+				"review_job_id": job.ID.Hex(),
 			},
 		}
 
-		// uses context to clean resources after performing updates in database
-		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
-		defer cancel()
-
-		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
 		// uses UpdateOne function to update movie data in database
-		result, err := movieCollection.UpdateOne(ctx, filter, update)
+		result, err := movieCollection.UpdateOne(ctx, bson.M{"imdb_id": movieId}, update)
 
 		// if error occurs, uses context to write json response with error message
 		if err != nil {
@@ -212,89 +472,30 @@ func AdminReviewUpdate(client *mongo.Client) gin.HandlerFunc {
 		}
 		// checks result of update operation, uses context to write json response with error message
 		if result.MatchedCount == 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Movie not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 			return
 		}
-		// sets response fields with updated data
-		resp.RankingName = sentiment
-		resp.AdminReview = req.AdminReview
-
-		// uses context to write json response
-		c.JSON(http.StatusOK, resp)
-	}
-
-}
-
-// creates function that gets review rankings, this function won't work properly if you don't provide your openai api key(you have to deposit 5$ to get started with openai api key)
-func GetReviewRanking(admin_review string, client *mongo.Client, c *gin.Context) (string, int, error) {
-	// gets all rankings using GetRankings function
-	rankings, err := GetRankings(client, c)
-
-	if err != nil {
-		return "", 0, err
-	}
 
-	// defines sentiment delimited
-	sentimentDelimited := ""
+		// asks for new embedding, admin review is part of embedded text
+		embeddings.Notify()
 
-	// loops through all rankings to set sentiment delimited
-	for _, ranking := range rankings {
-		if ranking.RankingValue != 999 {
-			sentimentDelimited = sentimentDelimited + ranking.RankingName + ","
+		// puts job to review queue, marks review as failed if job can not be scheduled
+		if err := queue.Enqueue(ctx, job); err != nil {
+			_, _ = movieCollection.UpdateOne(ctx, bson.M{"imdb_id": movieId, "review_job_id": job.ID.Hex()},
+				bson.M{"$set": bson.M{"review_status": models.ReviewStatusFailed}})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while scheduling review ranking"})
+			return
 		}
-	}
-
-	// trims sentiment delimited to remove trailing comma
-	sentimentDelimited = strings.Trim(sentimentDelimited, ",")
-
-	// loads env variables
-	err = godotenv.Load(".env")
 
-	if err != nil {
-		log.Println("Warning: .env file not found")
+		// uses context to write json response with job that can be polled for ranking
+		c.JSON(http.StatusAccepted, gin.H{
+			"admin_review":  req.AdminReview,
+			"review_status": models.ReviewStatusPending,
+			"job_id":        job.ID.Hex(),
+			"status_url":    "/review-jobs/" + job.ID.Hex(),
+		})
 	}
 
-	// gets openai api key from env
-	OpenAiApiKey := os.Getenv("OPENAI_API_KEY")
-
-	// if openai api key is empty, throws error
-	if OpenAiApiKey == "" {
-		return "", 0, errors.New("could not read OPENAI_API_KEY")
-	}
-
-	// defines llm needed for completion using openai
-	llm, err := openai.New(openai.WithToken(OpenAiApiKey))
-
-	if err != nil {
-		return "", 0, err
-	}
-
-	// gets base prompt template from env
-	base_prompt_template := os.Getenv("BASE_PROMPT_TEMPLATE")
-
-	// replaces {rankings} with sentiment delimited
-	base_prompt := strings.Replace(base_prompt_template, "{rankings}", sentimentDelimited, 1)
-
-	// defines response using llm based on admin review and base prompt
-	response, err := llm.Call(context.Background(), base_prompt+admin_review)
-
-	if err != nil {
-		return "", 0, err
-	}
-
-	// defines rank value
-	rankVal := 0
-
-	// loops through all rankings to set rank value
-	for _, ranking := range rankings {
-		if ranking.RankingName == response {
-			rankVal = ranking.RankingValue
-			break
-		}
-	}
-
-	return response, rankVal, nil
-
 }
 
 // creates function that gets rankings
@@ -329,7 +530,8 @@ func GetRankings(client *mongo.Client, c *gin.Context) ([]models.Ranking, error)
 
 }
 
-// creates function that handles request to get recommended movies based on user prompt
+// creates function that handles request to get recommended movies, movies are scored by genre affinity, user ratings and watch history,
+// movies liked by users with similar taste and popularity, already watched movies are skipped and genres of picks are kept diverse
 func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 	// returns anonymous function that works with gin context type
 	return func(c *gin.Context) {
@@ -348,65 +550,33 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		// loads env variables
-		err = godotenv.Load(".env")
-		// if error occurs, shows warning
-		if err != nil {
-			log.Println("Warning: .env file not found")
-		}
-		// defines limit for recommended movies
-		var recommendedMovieLimitVal int64 = 5
-		// defines limit for recommended movies in string form
-		recommendedMovieLimitStr := os.Getenv("RECOMMENDED_MOVIE_LIMIT")
-
-		// checks if limit for recommended movies is set
-		if recommendedMovieLimitStr != "" {
-			// converts limit for recommended movies to int
-			recommendedMovieLimitVal, _ = strconv.ParseInt(recommendedMovieLimitStr, 10, 64)
-		}
-
-		// defines find options of movies
-		findOptions := options.Find()
-		// uses set sort function to set sort order of movies by ranking in descending order
-		findOptions.SetSort(bson.D{{Key: "ranking.ranking_value", Value: 1}})
-
-		// sets limit for recommended movies
-		findOptions.SetLimit(recommendedMovieLimitVal)
-
-		// defines filter based on favourite genres
-		filter := bson.D{
-			{Key: "genre.genre_name", Value: bson.D{
-				{Key: "$in", Value: favourite_genres},
-			}},
-		}
 
 		// uses context to cancel request if timeout occurs
 		ctx, cancel := context.WithTimeout(c, 100*time.Second)
 		// cancels request when function ends(to prevent memory leaks)
 		defer cancel()
 
-		// defines movieCollection that is type of mongo collection defined in database
-		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+		// loads taste profile of user
+		profile, err := recommender.LoadProfile(ctx, client, userId, favourite_genres)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while loading user taste profile"})
+			return
+		}
 
-		// defines cursor for iteration
-		cursor, err := movieCollection.Find(ctx, filter, findOptions)
-		// if error occurs, uses context to write json response with error message
+		// loads movies worth scoring
+		candidates, err := recommender.LoadCandidates(ctx, client, profile)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movies from database"})
 			return
 		}
 
-		// after function ends, closes cursor
-		defer cursor.Close(ctx)
+		// scores movies and picks diverse best ones
+		recommendations := recommender.Recommend(candidates, profile, RecommendationOptions())
 
 		// defines recommended movies
-		var recommendedMovies []models.Movie
-		// passes cursor to recommendedMovies variable
-		if err := cursor.All(ctx, &recommendedMovies);
-		// if error occurs, uses context to write json response with error message and status
-		err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		recommendedMovies := make([]models.Movie, 0, len(recommendations))
+		for _, recommendation := range recommendations {
+			recommendedMovies = append(recommendedMovies, recommendation.Movie)
 		}
 
 		// uses context to write json response with recommended movies and ok status
@@ -414,6 +584,34 @@ func GetRecommendedMovies(client *mongo.Client) gin.HandlerFunc {
 	}
 }
 
+// creates function that gets recommendation options, limit is read from RECOMMENDED_MOVIE_LIMIT env and genre cap from RECOMMENDED_MAX_PER_GENRE env
+func RecommendationOptions() recommender.Options {
+	// loads env variables
+	err := godotenv.Load(".env")
+	// if error occurs, shows warning
+	if err != nil {
+		log.Println("Warning: .env file not found")
+	}
+	// defines limit for recommended movies
+	recommendedMovieLimitVal := 5
+	// checks if limit for recommended movies is set
+	if value, err := strconv.Atoi(os.Getenv("RECOMMENDED_MOVIE_LIMIT")); err == nil && value > 0 {
+		recommendedMovieLimitVal = value
+	}
+
+	// defines how many picks can share one genre, by default half of picks
+	maxPerGenre := (recommendedMovieLimitVal + 1) / 2
+	if value, err := strconv.Atoi(os.Getenv("RECOMMENDED_MAX_PER_GENRE")); err == nil && value >= 0 {
+		maxPerGenre = value
+	}
+
+	return recommender.Options{
+		Limit:       recommendedMovieLimitVal,
+		MaxPerGenre: maxPerGenre,
+		Weights:     recommender.DefaultWeights,
+	}
+}
+
 // creates function that gets User Favourite Genres
 func GetUsersFavouriteGenres(userId string, client *mongo.Client, c *gin.Context) ([]string, error) {
 	// uses context to cancel request if timeout occurs
@@ -0,0 +1,280 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/mailer"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// defines default password reset settings
+const (
+	defaultPasswordResetTTL = time.Hour
+	defaultPasswordResetURL = "http://localhost:5173/reset-password"
+)
+
+// creates function that handles post request to /me/password endpoint to change password of logged in user
+func ChangePassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// uses ShouldBindJSON function to bind json request body to password change struct
+		var req models.PasswordChange
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate password change
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// finds user by id
+		userCollection := database.OpenCollection("users", client)
+		var user models.User
+		if err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching user"})
+			return
+		}
+
+		// compares old password with hashed password
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Old password is incorrect"})
+			return
+		}
+
+		// saves new password, session of this device stays logged in
+		if err := setPassword(ctx, client, userId, req.NewPassword, utils.GetSessionIdFromContext(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+	}
+}
+
+// creates function that handles post request to /password/forgot endpoint to email password reset link, requests are limited
+// per email and per client ip, response is sent before account is looked up and does not depend on it, so that neither its content
+// nor its timing tells which emails have accounts
+func ForgotPassword(client *mongo.Client, mail mailer.Mailer, throttle *loginguard.Throttle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// uses ShouldBindJSON function to bind json request body to password forgot struct
+		var req models.PasswordForgot
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate email
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// limits reset emails of account and client ip, emails without account are counted too
+		decision, err := throttle.Allow(ctx, req.Email, c.ClientIP())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check password reset requests"})
+			return
+		}
+		if !decision.Allowed {
+			tooManyRequests(c, decision.RetryAfter, "Too many password reset requests, try again later")
+			return
+		}
+
+		// sends reset link in background
+		go sendPasswordReset(client, mail, req.Email)
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "If an account with this email exists, a password reset link has been sent"})
+	}
+}
+
+// creates function that creates reset token of account with given email and emails link with it, it runs after response is sent,
+// so errors are only logged, earlier links of account keep working until they expire or password is changed
+func sendPasswordReset(client *mongo.Client, mail mailer.Mailer, email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	// finds user by email
+	var user models.User
+	err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return
+	}
+	if err != nil {
+		log.Println("Warning: unable to find user of password reset:", err)
+		return
+	}
+
+	// generates reset token, only its hash is stored
+	token, hash, err := utils.GenerateSecretToken()
+	if err != nil {
+		log.Println("Warning: unable to generate password reset token:", err)
+		return
+	}
+	ttl := passwordResetTTL()
+	now := time.Now()
+	_, err = database.OpenCollection("password_resets", client).InsertOne(ctx, models.PasswordReset{
+		UserID:    user.UserID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		log.Println("Warning: unable to store password reset token:", err)
+		return
+	}
+
+	// sends reset link
+	err = mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your MagicStream password",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"Use the link below to choose a new password. It works once and expires in " + ttl.String() + ".\n\n" +
+			linkWithToken(os.Getenv("PASSWORD_RESET_URL"), defaultPasswordResetURL, token) + "\n\n" +
+			"If you did not ask to reset your password, you can ignore this email.\n",
+	})
+	if err != nil {
+		log.Println("Warning: unable to send password reset email:", err)
+	}
+}
+
+// creates function that handles post request to /password/reset endpoint to set new password using token from reset email
+func ResetPassword(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// uses ShouldBindJSON function to bind json request body to password reset struct
+		var req models.PasswordResetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate password reset
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// marks token as used in the same operation that checks it, so that token can not be used twice by parallel requests
+		now := time.Now()
+		var reset models.PasswordReset
+		err := database.OpenCollection("password_resets", client).FindOneAndUpdate(ctx,
+			bson.M{
+				"token_hash": utils.HashSecretToken(req.Token),
+				"used_at":    bson.M{"$exists": false},
+				"expires_at": bson.M{"$gt": now},
+			},
+			bson.M{"$set": bson.M{"used_at": now}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&reset)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired reset token"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking reset token"})
+			return
+		}
+
+		// saves new password, all sessions are revoked
+		if err := setPassword(ctx, client, reset.UserID, req.NewPassword, ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+	}
+}
+
+// creates function that hashes and saves new password of user, revokes sessions of user except kept one and drops unused reset tokens
+func setPassword(ctx context.Context, client *mongo.Client, userId, password, keepSessionId string) error {
+	// hashes password
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// saves password
+	result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+		bson.M{"user_id": userId},
+		bson.M{"$set": bson.M{"password": hashedPassword, "update_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	// logs user out of other devices, whoever knew old password should lose access
+	sessionFilter := bson.M{"user_id": userId}
+	if id, err := bson.ObjectIDFromHex(keepSessionId); err == nil {
+		sessionFilter["_id"] = bson.M{"$ne": id}
+	}
+	if _, err := utils.RevokeSessions(ctx, client, sessionFilter, models.SessionRevokedPasswordChange); err != nil {
+		return err
+	}
+
+	// removes reset tokens of user, they were sent before password changed
+	if _, err := database.OpenCollection("password_resets", client).DeleteMany(ctx, bson.M{"user_id": userId, "used_at": bson.M{"$exists": false}}); err != nil {
+		log.Println("Warning: unable to remove password reset tokens:", err)
+	}
+	return nil
+}
+
+// creates function that gets how long password reset token works, can be set in minutes with PASSWORD_RESET_TTL_MINUTES env
+func passwordResetTTL() time.Duration {
+	if minutes, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_TTL_MINUTES")); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultPasswordResetTTL
+}
+
+// creates function that adds token query parameter to link, fallback link is used when link from env is empty or invalid
+func linkWithToken(link, fallback, token string) string {
+	parsed, err := url.Parse(link)
+	if link == "" || err != nil {
+		parsed, _ = url.Parse(fallback)
+	}
+	query := parsed.Query()
+	query.Set("token", token)
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
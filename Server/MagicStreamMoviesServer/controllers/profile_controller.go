@@ -0,0 +1,168 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines error returned when user picks genre that does not exist
+var errUnknownGenre = errors.New("unknown genre")
+
+// defines projection of user fields that are part of profile
+var userProfileProjection = bson.M{
+	"_id": 0, "user_id": 1, "first_name": 1, "last_name": 1, "email": 1, "role": 1,
+	"favourite_genres": 1, "email_verified": 1, "created_at": 1, "update_at": 1,
+}
+
+// creates function that handles get request to /me endpoint to get profile of logged in user
+func GetProfile(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// finds user by id
+		var profile models.UserProfile
+		err = database.OpenCollection("users", client).
+			FindOne(ctx, bson.M{"user_id": userId}, options.FindOne().SetProjection(userProfileProjection)).
+			Decode(&profile)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching user"})
+			return
+		}
+
+		// uses context to write json response with profile
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// creates function that handles patch request to /me endpoint to update name and favourite genres of logged in user
+func UpdateProfile(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// uses ShouldBindJSON function to bind json request body to profile update struct
+		var req models.UserProfileUpdate
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate profile update
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// defines fields to update
+		set := bson.M{"update_at": time.Now()}
+		if req.FirstName != nil {
+			set["first_name"] = *req.FirstName
+		}
+		if req.LastName != nil {
+			set["last_name"] = *req.LastName
+		}
+		if req.FavouriteGenres != nil {
+			// takes genre names from genres collection
+			genres, err := resolveGenres(ctx, client, *req.FavouriteGenres)
+			if err != nil {
+				if errors.Is(err, errUnknownGenre) {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching movie genres"})
+				return
+			}
+			set["favourite_genres"] = genres
+		}
+
+		// updates user and gets updated profile
+		var profile models.UserProfile
+		err = database.OpenCollection("users", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": userId},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(userProfileProjection),
+		).Decode(&profile)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while updating user"})
+			return
+		}
+
+		// uses context to write json response with updated profile
+		c.JSON(http.StatusOK, profile)
+	}
+}
+
+// creates function that turns genre ids picked by user into genres stored in genres collection, keeps order of picks and drops repeated ones
+func resolveGenres(ctx context.Context, client *mongo.Client, selections []models.GenreSelection) ([]models.Genre, error) {
+	// defines requested genre ids
+	ids := make([]int, 0, len(selections))
+	for _, selection := range selections {
+		ids = append(ids, selection.GenreID)
+	}
+
+	// finds genres with requested ids
+	var found []models.Genre
+	cursor, err := database.OpenCollection("genres", client).Find(ctx, bson.M{"genre_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	genresById := make(map[int]models.Genre, len(found))
+	for _, genre := range found {
+		genresById[genre.GenreID] = genre
+	}
+
+	// builds genres in order of picks
+	genres := make([]models.Genre, 0, len(ids))
+	added := map[int]bool{}
+	for _, id := range ids {
+		genre, ok := genresById[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: genre_id %d does not exist", errUnknownGenre, id)
+		}
+		if !added[id] {
+			added[id] = true
+			genres = append(genres, genre)
+		}
+	}
+	return genres, nil
+}
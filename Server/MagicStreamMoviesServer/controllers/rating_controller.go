@@ -0,0 +1,181 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// creates function that handles put request to /movie/:imdb_id/rating endpoint to submit or change user star rating of movie
+func RateMovie(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets movie id from url parameter
+		movieId := c.Param("imdb_id")
+
+		// defines rating request struct
+		var req struct {
+			Rating int `json:"rating" validate:"required,min=1,max=10"`
+		}
+		// uses ShouldBindJSON function to bind json request body to rating request struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate rating
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// checks if movie exists
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+		count, err := movieCollection.CountDocuments(ctx, bson.M{"imdb_id": movieId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movie from database"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		// saves rating and reads previous rating of user
+		now := time.Now()
+		var previous models.Rating
+		err = database.OpenCollection("ratings", client).FindOneAndUpdate(ctx,
+			bson.M{"user_id": userId, "imdb_id": movieId},
+			bson.M{
+				"$set":         bson.M{"rating": req.Rating, "updated_at": now},
+				"$setOnInsert": bson.M{"created_at": now},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+		).Decode(&previous)
+
+		// defines changes of rating count and sum
+		var countDelta, sumDelta int64
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			// user rates movie for the first time
+			countDelta, sumDelta = 1, int64(req.Rating)
+		case err != nil:
+			// rating may still have been saved when request was cancelled, so score is recounted from ratings
+			if _, err := recountAudienceScore(client, movieId); err != nil {
+				log.Println("Warning: unable to recount audience score:", err)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while saving rating"})
+			return
+		default:
+			// user changes rating
+			sumDelta = int64(req.Rating - previous.Rating)
+		}
+
+		// updates audience score of movie, when it fails after rating was saved score is recounted from ratings
+		// so that count and sum do not drift from ratings collection
+		score, err := updateAudienceScore(ctx, movieCollection, movieId, countDelta, sumDelta)
+		if err != nil {
+			log.Println("Warning: unable to update audience score, recounting it from ratings:", err)
+			if _, err := recountAudienceScore(client, movieId); err != nil {
+				log.Println("Warning: unable to recount audience score:", err)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while updating audience score"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"rating": req.Rating, "audience_score": score})
+	}
+}
+
+// creates function that changes rating count and sum of movie and recounts mean and bayesian weighted score in one atomic update
+func updateAudienceScore(ctx context.Context, movieCollection *mongo.Collection, movieId string, countDelta, sumDelta int64) (models.AudienceScore, error) {
+	return setAudienceScore(ctx, movieCollection, movieId,
+		bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$audience_score.count", 0}}, countDelta}},
+		bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$audience_score.sum", 0}}, sumDelta}},
+	)
+}
+
+// creates function that recounts rating count and sum of movie from ratings collection, it uses its own context
+// because request context can already be cancelled
+func recountAudienceScore(client *mongo.Client, movieId string) (models.AudienceScore, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// sums ratings of movie
+	cursor, err := database.OpenCollection("ratings", client).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"imdb_id": movieId}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "sum": bson.M{"$sum": "$rating"}}}},
+	})
+	if err != nil {
+		return models.AudienceScore{}, err
+	}
+	var totals []struct {
+		Count int64 `bson:"count"`
+		Sum   int64 `bson:"sum"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return models.AudienceScore{}, err
+	}
+	var count, sum int64
+	if len(totals) > 0 {
+		count, sum = totals[0].Count, totals[0].Sum
+	}
+
+	return setAudienceScore(ctx, database.OpenCollection("movies", client), movieId, count, sum)
+}
+
+// creates function that sets rating count and sum of movie to given values or expressions and recounts mean and bayesian weighted score
+func setAudienceScore(ctx context.Context, movieCollection *mongo.Collection, movieId string, count, sum any) (models.AudienceScore, error) {
+	// gets bayesian prior
+	priorMean, priorWeight := utils.RatingPrior()
+
+	// defines update pipeline, second stage uses values set by the first one
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"audience_score.count": count,
+			"audience_score.sum":   sum,
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"audience_score.mean": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$audience_score.count", 0}},
+				bson.M{"$divide": bson.A{"$audience_score.sum", "$audience_score.count"}},
+				0,
+			}},
+			"audience_score.weighted_score": bson.M{"$divide": bson.A{
+				bson.M{"$add": bson.A{priorMean * priorWeight, "$audience_score.sum"}},
+				bson.M{"$add": bson.A{priorWeight, "$audience_score.count"}},
+			}},
+		}}},
+	}
+
+	// updates movie and reads updated audience score
+	var movie models.Movie
+	err := movieCollection.FindOneAndUpdate(ctx, bson.M{"imdb_id": movieId}, pipeline,
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"audience_score": 1}),
+	).Decode(&movie)
+	if err != nil {
+		return models.AudienceScore{}, err
+	}
+
+	return movie.AudienceScore, nil
+}
@@ -0,0 +1,100 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/recommender"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// creates function that handles post request to /recommendedmovies endpoint, turns free text request of user into genre and ranking filters
+// and recommends matching movies with short explanation of every pick
+func RecommendMoviesFromPrompt(client *mongo.Client, parser llm.PromptParser) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// defines prompt request struct
+		var req struct {
+			Prompt string `json:"prompt" validate:"required,min=3,max=500"`
+		}
+		// uses ShouldBindJSON function to bind json request body to prompt request struct
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		// uses validator to validate prompt
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// calls get user favourite genres function to get user favourite genres
+		favouriteGenres, err := GetUsersFavouriteGenres(userId, client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// gets rankings that prompt can ask for
+		rankings, err := GetRankings(client, c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching rankings"})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// gets genres that prompt can ask for
+		var genres []models.Genre
+		cursor, err := database.OpenCollection("genres", client).Find(ctx, bson.M{})
+		if err == nil {
+			err = cursor.All(ctx, &genres)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching movie genres"})
+			return
+		}
+
+		// parses prompt into filters, prompt that can not be parsed gets usual recommendations of user
+		query, err := parser.Parse(ctx, req.Prompt, genres, rankings)
+		if err != nil {
+			log.Println("Warning: unable to parse recommendation prompt, using usual recommendations:", err)
+			query = models.RecommendationQuery{}
+		}
+
+		// loads taste profile of user
+		profile, err := recommender.LoadProfile(ctx, client, userId, favouriteGenres)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while loading user taste profile"})
+			return
+		}
+
+		// recommends movies matching filters
+		recommendations, query, err := recommender.RecommendForQuery(ctx, client, profile, query, RecommendationOptions())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movies from database"})
+			return
+		}
+
+		// uses context to write json response with filters that were used and recommended movies with explanations
+		c.JSON(http.StatusOK, gin.H{"query": query, "recommendations": recommendations})
+	}
+}
@@ -0,0 +1,349 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/jobs"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines review request struct shared by create and edit handlers
+type reviewRequest struct {
+	Text string `json:"text" validate:"required,min=2,max=5000"`
+}
+
+// creates function that handles get request to /movie/:imdb_id/reviews endpoint to get page of user reviews of movie
+func GetMovieReviews(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id and role from context
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		role, _ := utils.GetRoleFromContext(c)
+
+		// reads pagination from query parameters
+		pagination, err := utils.GetPagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// defines filter, hidden reviews are visible only to admins and to their authors
+		filter := bson.M{"imdb_id": c.Param("imdb_id")}
+		if role != "ADMIN" {
+			filter["$or"] = bson.A{bson.M{"hidden": false}, bson.M{"user_id": userId}}
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// opens reviews collection from database
+		var reviewCollection *mongo.Collection = database.OpenCollection("reviews", client)
+
+		// counts reviews that match filter
+		totalCount, err := reviewCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while counting reviews"})
+			return
+		}
+
+		// gets newest reviews first
+		findOptions := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetSkip(pagination.Skip()).
+			SetLimit(pagination.Limit)
+		cursor, err := reviewCollection.Find(ctx, filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching reviews from database"})
+			return
+		}
+		// closes cursor after function ends(in any case)
+		defer cursor.Close(ctx)
+
+		// passes cursor to reviews variable
+		reviews := []models.Review{}
+		if err := cursor.All(ctx, &reviews); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode reviews from database"})
+			return
+		}
+
+		// uses context to write json response with reviews page
+		c.JSON(http.StatusOK, models.ReviewPage{
+			Reviews:      reviews,
+			PageMetadata: utils.BuildPageMetadata(c, pagination, totalCount),
+		})
+	}
+}
+
+// creates function that handles post request to /movie/:imdb_id/reviews endpoint to write user review, user can write one review per movie
+func CreateReview(client *mongo.Client, queue *jobs.ReviewQueue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets movie id from url parameter
+		movieId := c.Param("imdb_id")
+
+		// binds and validates request body
+		req, ok := bindReviewRequest(c)
+		if !ok {
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// checks if movie exists
+		count, err := database.OpenCollection("movies", client).CountDocuments(ctx, bson.M{"imdb_id": movieId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movie from database"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		// gets name of review author
+		var author models.User
+		err = database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": userId},
+			options.FindOne().SetProjection(bson.M{"first_name": 1, "last_name": 1})).Decode(&author)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching user from database"})
+			return
+		}
+
+		// defines review with pending ranking and job that ranks it
+		now := time.Now()
+		review := models.Review{
+			ID:           bson.NewObjectID(),
+			ImdbID:       movieId,
+			UserID:       userId,
+			AuthorName:   strings.TrimSpace(author.FirstName + " " + author.LastName),
+			Text:         req.Text,
+			ReviewStatus: models.ReviewStatusPending,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		job := jobs.NewReviewJob(models.ReviewKindUser, review.ID.Hex(), review.Text, userId)
+		review.ReviewJobID = job.ID.Hex()
+
+		// saves review, unique index allows only one review of user per movie
+		var reviewCollection *mongo.Collection = database.OpenCollection("reviews", client)
+		if _, err := reviewCollection.InsertOne(ctx, review); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				var existing models.Review
+				_ = reviewCollection.FindOne(ctx, bson.M{"imdb_id": movieId, "user_id": userId}).Decode(&existing)
+				c.JSON(http.StatusConflict, gin.H{"error": "You have already reviewed this movie", "review_id": existing.ID})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while saving review"})
+			return
+		}
+
+		// puts job to review queue
+		if !enqueueReviewJob(ctx, c, reviewCollection, queue, job) {
+			return
+		}
+
+		c.JSON(http.StatusCreated, review)
+	}
+}
+
+// creates function that handles patch request to /reviews/:review_id endpoint to edit own review, edited review is ranked again
+func UpdateReview(client *mongo.Client, queue *jobs.ReviewQueue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets review id from url parameter
+		reviewId, err := bson.ObjectIDFromHex(c.Param("review_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review id"})
+			return
+		}
+
+		// binds and validates request body
+		req, ok := bindReviewRequest(c)
+		if !ok {
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// defines job that ranks edited review
+		job := jobs.NewReviewJob(models.ReviewKindUser, reviewId.Hex(), req.Text, userId)
+
+		// updates review only if it belongs to user
+		var reviewCollection *mongo.Collection = database.OpenCollection("reviews", client)
+		var review models.Review
+		err = reviewCollection.FindOneAndUpdate(ctx,
+			bson.M{"_id": reviewId, "user_id": userId},
+			bson.M{"$set": bson.M{
+				"text":          req.Text,
+				"review_status": models.ReviewStatusPending,
+				"review_job_id": job.ID.Hex(),
+				"updated_at":    time.Now(),
+			}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&review)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while updating review"})
+			return
+		}
+
+		// puts job to review queue
+		if !enqueueReviewJob(ctx, c, reviewCollection, queue, job) {
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// creates function that handles delete request to /reviews/:review_id endpoint to delete own review
+func DeleteReview(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets review id from url parameter
+		reviewId, err := bson.ObjectIDFromHex(c.Param("review_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review id"})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// deletes review only if it belongs to user
+		result, err := database.OpenCollection("reviews", client).DeleteOne(ctx, bson.M{"_id": reviewId, "user_id": userId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while deleting review"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+	}
+}
+
+// creates function that handles patch request to /reviews/:review_id/visibility endpoint to hide or unhide review as admin
+func SetReviewVisibility(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts admin user id using GetUserIdFromContext function
+		adminId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets review id from url parameter
+		reviewId, err := bson.ObjectIDFromHex(c.Param("review_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review id"})
+			return
+		}
+
+		// defines visibility request struct, pointer is used to tell missing field from false
+		var req struct {
+			Hidden *bool `json:"hidden" validate:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+			return
+		}
+
+		// defines update, remembers admin who hid review
+		update := bson.M{"$set": bson.M{"hidden": *req.Hidden, "hidden_by": adminId}}
+		if !*req.Hidden {
+			update = bson.M{"$set": bson.M{"hidden": false}, "$unset": bson.M{"hidden_by": ""}}
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// updates review
+		var review models.Review
+		err = database.OpenCollection("reviews", client).FindOneAndUpdate(ctx, bson.M{"_id": reviewId}, update,
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&review)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while updating review"})
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// creates function that binds and validates review request body, writes json response with error if request is invalid
+func bindReviewRequest(c *gin.Context) (reviewRequest, bool) {
+	var req reviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return req, false
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if err := validate.Struct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+// creates function that puts review job to queue, marks review as failed and writes json response with error if job can not be scheduled
+func enqueueReviewJob(ctx context.Context, c *gin.Context, reviewCollection *mongo.Collection, queue *jobs.ReviewQueue, job models.ReviewJob) bool {
+	if err := queue.Enqueue(ctx, job); err != nil {
+		log.Println("Warning: unable to schedule review ranking:", err)
+		_, _ = reviewCollection.UpdateOne(ctx, bson.M{"review_job_id": job.ID.Hex()},
+			bson.M{"$set": bson.M{"review_status": models.ReviewStatusFailed}})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while scheduling review ranking"})
+		return false
+	}
+	return true
+}
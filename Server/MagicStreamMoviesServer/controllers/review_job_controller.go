@@ -0,0 +1,117 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/jobs"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines how long client waits for review job notification
+const reviewJobEventsTimeout = 90 * time.Second
+
+// creates function that handles get request to /review-jobs/:job_id endpoint to poll status of review ranking, job can be read
+// by user who wrote review and by admins
+func GetReviewJob(queue *jobs.ReviewQueue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gets job id from url parameter
+		jobID, err := bson.ObjectIDFromHex(c.Param("job_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job id"})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// gets job, jobs of other users are reported as not found
+		job, err := queue.GetJob(ctx, jobID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Review job not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching review job"})
+			return
+		}
+		if !canReadReviewJob(c, job) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review job not found"})
+			return
+		}
+
+		// uses context to write json response with job
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// creates function that handles get request to /review-jobs/:job_id/events endpoint, sends server-sent event once review ranking is ready
+func ReviewJobEvents(queue *jobs.ReviewQueue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gets job id from url parameter
+		jobID, err := bson.ObjectIDFromHex(c.Param("job_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job id"})
+			return
+		}
+
+		// subscribes before reading job, so that completion can not be missed between two calls
+		updates, unsubscribe := queue.Subscribe(jobID)
+		defer unsubscribe()
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, reviewJobEventsTimeout)
+		defer cancel()
+
+		// gets job, jobs of other users are reported as not found
+		job, err := queue.GetJob(ctx, jobID)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Review job not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching review job"})
+			return
+		}
+		if !canReadReviewJob(c, job) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review job not found"})
+			return
+		}
+
+		// sends job right away if it is already finished
+		if job.Status != models.ReviewStatusPending {
+			c.SSEvent(job.Status, job)
+			return
+		}
+
+		// streams events until job is finished, timeout occurs or client disconnects
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case job := <-updates:
+				c.SSEvent(job.Status, job)
+			case <-ctx.Done():
+				c.SSEvent("timeout", gin.H{"job_id": jobID.Hex(), "status": models.ReviewStatusPending})
+			}
+			return false
+		})
+	}
+}
+
+// creates function that checks if logged in user wrote review of job or is admin
+func canReadReviewJob(c *gin.Context, job models.ReviewJob) bool {
+	if role, err := utils.GetRoleFromContext(c); err == nil && role == "ADMIN" {
+		return true
+	}
+	userId, err := utils.GetUserIdFromContext(c)
+	return err == nil && job.UserID != "" && job.UserID == userId
+}
@@ -0,0 +1,244 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines limits of search
+const (
+	// maxTextSearchResults is maximum number of full-text matches taken into account by search
+	maxTextSearchResults = 500
+	// minTextHitsBeforeFuzzy is number of full-text matches below which typo tolerant title matching runs
+	minTextHitsBeforeFuzzy = 20
+	// maxFuzzyCandidates is maximum number of titles compared with query by typo tolerant matching
+	maxFuzzyCandidates = 1000
+	// fuzzyPrefixLength is number of first letters of query term that title word has to start with to be compared,
+	// so typo in one of these letters (like "mtarix" for "matrix") is not found, it is price of not comparing whole catalog
+	fuzzyPrefixLength = 2
+)
+
+// defines searchHit struct that holds movie id and its relevance score
+type searchHit struct {
+	ImdbID string
+	Title  string
+	Score  float64
+}
+
+// creates function that handles get request to /movies/search endpoint to search movies by title and admin review
+func SearchMovies(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gets search query from query parameter
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Search query q is required"})
+			return
+		}
+
+		// reads pagination from query parameters
+		pagination, err := utils.GetPagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// builds filter from the same query parameters as movies listing
+		filter, err := BuildMovieFilter(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs - when function ends(after 100 seconds)
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// opens movies collection from database
+		var movieCollection *mongo.Collection = database.OpenCollection("movies", client)
+
+		// finds relevant movies using text index and fuzzy title matching
+		hits, err := findSearchHits(ctx, movieCollection, query, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while searching movies in database"})
+			return
+		}
+
+		// cuts hits of current page
+		start := min(pagination.Skip(), int64(len(hits)))
+		end := min(start+pagination.Limit, int64(len(hits)))
+		pageHits := hits[start:end]
+
+		// loads full movie documents of current page
+		movies, err := loadMoviesInOrder(ctx, movieCollection, pageHits)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode movies from database"})
+			return
+		}
+
+		// uses context to write json response with movies ordered by relevance and page metadata
+		c.JSON(http.StatusOK, models.MoviePage{
+			Movies:       movies,
+			PageMetadata: utils.BuildPageMetadata(c, pagination, int64(len(hits))),
+		})
+	}
+}
+
+// creates function that combines text index matches with prefix and typo tolerant title matches and orders them by relevance
+func findSearchHits(ctx context.Context, movieCollection *mongo.Collection, query string, filter bson.M) ([]searchHit, error) {
+	// defines hits by movie id
+	hitsByID := map[string]*searchHit{}
+
+	// defines text search filter on top of listing filter
+	textFilter := bson.M{"$text": bson.M{"$search": query}}
+	for key, value := range filter {
+		textFilter[key] = value
+	}
+
+	// finds movies using text index and sorts them by text score
+	textOptions := options.Find().
+		SetProjection(bson.M{"imdb_id": 1, "title": 1, "score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(maxTextSearchResults)
+	cursor, err := movieCollection.Find(ctx, textFilter, textOptions)
+	if err != nil {
+		return nil, err
+	}
+	var textMatches []struct {
+		ImdbID string  `bson:"imdb_id"`
+		Title  string  `bson:"title"`
+		Score  float64 `bson:"score"`
+	}
+	if err := cursor.All(ctx, &textMatches); err != nil {
+		return nil, err
+	}
+	// text score has no upper bound, so it is divided by the best one to be in the same 0-1 range as title match score
+	bestTextScore := 0.0
+	for _, match := range textMatches {
+		bestTextScore = max(bestTextScore, match.Score)
+	}
+	for _, match := range textMatches {
+		hitsByID[match.ImdbID] = &searchHit{ImdbID: match.ImdbID, Title: match.Title, Score: normalizeScore(match.Score, bestTextScore)}
+	}
+
+	// finds movie titles that match query terms by prefix or with typos, text index only matches whole stemmed words,
+	// it runs only when text index found few movies and compares only titles that have word starting like one of the terms
+	terms := utils.Tokenize(query)
+	if len(textMatches) >= minTextHitsBeforeFuzzy || len(terms) == 0 {
+		return sortSearchHits(hitsByID), nil
+	}
+	fuzzyFilter := bson.M{"$and": bson.A{filter, bson.M{"title": bson.M{"$regex": fuzzyPrefilterPattern(terms), "$options": "i"}}}}
+	fuzzyOptions := options.Find().
+		SetProjection(bson.M{"_id": 0, "imdb_id": 1, "title": 1}).
+		SetLimit(maxFuzzyCandidates)
+	cursor, err = movieCollection.Find(ctx, fuzzyFilter, fuzzyOptions)
+	if err != nil {
+		return nil, err
+	}
+	var titles []struct {
+		ImdbID string `bson:"imdb_id"`
+		Title  string `bson:"title"`
+	}
+	if err := cursor.All(ctx, &titles); err != nil {
+		return nil, err
+	}
+	for _, title := range titles {
+		// averages how well each query term matches title words, so that score is between 0 and 1
+		words := utils.Tokenize(title.Title)
+		score := 0.0
+		for _, term := range terms {
+			score += utils.MatchTermScore(term, words)
+		}
+		if score == 0 {
+			continue
+		}
+		score /= float64(len(terms))
+		// adds title score to already found hit or creates a new one, movie matched by both text index and title ranks first
+		if hit, ok := hitsByID[title.ImdbID]; ok {
+			hit.Score += score
+		} else {
+			hitsByID[title.ImdbID] = &searchHit{ImdbID: title.ImdbID, Title: title.Title, Score: score}
+		}
+	}
+
+	return sortSearchHits(hitsByID), nil
+}
+
+// creates function that scales score to 0-1 range by dividing it by the best score
+func normalizeScore(score, best float64) float64 {
+	if best <= 0 {
+		return 0
+	}
+	return score / best
+}
+
+// creates function that orders hits by score and by title when scores are equal
+func sortSearchHits(hitsByID map[string]*searchHit) []searchHit {
+	hits := make([]searchHit, 0, len(hitsByID))
+	for _, hit := range hitsByID {
+		hits = append(hits, *hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Title < hits[j].Title
+	})
+	return hits
+}
+
+// creates function that builds regex matching titles with word that starts with first letters of one of the terms
+func fuzzyPrefilterPattern(terms []string) string {
+	prefixes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		runes := []rune(term)
+		prefixes = append(prefixes, regexp.QuoteMeta(string(runes[:min(len(runes), fuzzyPrefixLength)])))
+	}
+	return `\b(?:` + strings.Join(prefixes, "|") + `)`
+}
+
+// creates function that loads movies of given hits keeping order of hits
+func loadMoviesInOrder(ctx context.Context, movieCollection *mongo.Collection, hits []searchHit) ([]models.Movie, error) {
+	// defines movie ids
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ImdbID
+	}
+
+	// finds movies by ids
+	cursor, err := movieCollection.Find(ctx, bson.M{"imdb_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var found []models.Movie
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	// restores order of hits
+	moviesByID := make(map[string]models.Movie, len(found))
+	for _, movie := range found {
+		moviesByID[movie.ImdbID] = movie
+	}
+	movies := make([]models.Movie, 0, len(ids))
+	for _, id := range ids {
+		if movie, ok := moviesByID[id]; ok {
+			movies = append(movies, movie)
+		}
+	}
+
+	return movies, nil
+}
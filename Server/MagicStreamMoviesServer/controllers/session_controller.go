@@ -0,0 +1,110 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// creates function that handles get request to /me/sessions endpoint to list devices that user is logged in on
+func GetSessions(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// gets active sessions of user
+		sessions, err := utils.GetActiveSessions(ctx, client, userId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching sessions"})
+			return
+		}
+
+		// marks session of this device
+		currentSessionId := utils.GetSessionIdFromContext(c)
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID.Hex() == currentSessionId
+		}
+
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// creates function that handles delete request to /me/sessions/:session_id endpoint to log user out of one device
+func RevokeSession(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets session id from url parameter
+		sessionId, err := bson.ObjectIDFromHex(c.Param("session_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session id"})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// revokes session, filter on user id keeps users from revoking sessions of others
+		revoked, err := utils.RevokeSessions(ctx, client, bson.M{"_id": sessionId, "user_id": userId}, models.SessionRevokedByUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while revoking session"})
+			return
+		}
+		if revoked == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+	}
+}
+
+// creates function that handles delete request to /me/sessions endpoint to log user out of all other devices
+func RevokeOtherSessions(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// revokes sessions of user except session of this device
+		filter := bson.M{"user_id": userId}
+		if sessionId, err := bson.ObjectIDFromHex(utils.GetSessionIdFromContext(c)); err == nil {
+			filter["_id"] = bson.M{"$ne": sessionId}
+		}
+		revoked, err := utils.RevokeSessions(ctx, client, filter, models.SessionRevokedByUser)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while revoking sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked successfully", "revoked": revoked})
+	}
+}
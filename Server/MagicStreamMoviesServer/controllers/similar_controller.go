@@ -0,0 +1,59 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/recommender"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines default and max number of similar movies
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 50
+)
+
+// creates function that handles get request to /movie/:imdb_id/similar endpoint to get movies most similar to given movie
+func GetSimilarMovies(client *mongo.Client, embedder llm.Embedder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gets movie id from url parameter
+		movieId := c.Param("imdb_id")
+
+		// gets number of similar movies from query
+		limit := defaultSimilarLimit
+		if limitStr := c.Query("limit"); limitStr != "" {
+			value, err := strconv.Atoi(limitStr)
+			if err != nil || value < 1 || value > maxSimilarLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a number between 1 and " + strconv.Itoa(maxSimilarLimit)})
+				return
+			}
+			limit = value
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// finds nearest movies by embeddings
+		similar, err := recommender.SimilarMovies(ctx, client, embedder, movieId, limit)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while finding similar movies"})
+			return
+		}
+
+		// uses context to write json response with similar movies and their similarity
+		c.JSON(http.StatusOK, gin.H{"imdb_id": movieId, "similar": similar})
+	}
+}
@@ -4,13 +4,20 @@ package controllers
 // imports packages
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/mailer"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -32,7 +39,7 @@ func HashPassword(password string) (string, error) {
 }
 
 // creates function that handles post request to /register-user endpoint
-func RegisterUser(client *mongo.Client) gin.HandlerFunc {
+func RegisterUser(client *mongo.Client, mail mailer.Mailer) gin.HandlerFunc {
 	// returns anonymous function that works with gin context - context is used to pass data between handlers
 	return func(c *gin.Context) {
 		var user models.User
@@ -79,11 +86,29 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 			return
 		}
+		// takes favourite genres from genres collection by genre id, names sent by client are not trusted
+		selections := make([]models.GenreSelection, 0, len(user.FavouriteGenres))
+		for _, genre := range user.FavouriteGenres {
+			selections = append(selections, models.GenreSelection{GenreID: genre.GenreID})
+		}
+		user.FavouriteGenres, err = resolveGenres(ctx, client, selections)
+		if err != nil {
+			if errors.Is(err, errUnknownGenre) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error fetching movie genres"})
+			return
+		}
+
 		// defines user details
 		user.UserID = bson.NewObjectID().Hex()
 		user.CreatedAt = time.Now()
 		user.UpdatedAt = time.Now()
 		user.Password = hashedPassword
+		// email is verified later with link from verification email
+		user.EmailVerified = false
+		user.EmailVerifiedAt = nil
 
 		// uses InsertOne function to add new user data to database
 		result, err := userCollection.InsertOne(ctx, user)
@@ -94,6 +119,11 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
+		// sends verification email, user can ask for new one if it fails
+		if err := sendVerificationEmail(ctx, mail, user); err != nil {
+			log.Println("Warning: unable to send verification email:", err)
+		}
+
 		// uses context to write json response with user data
 		c.JSON(http.StatusCreated, result)
 
@@ -101,8 +131,9 @@ func RegisterUser(client *mongo.Client) gin.HandlerFunc {
 
 }
 
-// creates function that handles post request to /login-user endpoint
-func LoginUser(client *mongo.Client) gin.HandlerFunc {
+// creates function that handles post request to /login-user endpoint, failed attempts are counted by guard
+// that slows down and locks accounts and client ips under brute force
+func LoginUser(client *mongo.Client, guard *loginguard.Guard) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		// defines userLogin model struct
@@ -118,150 +149,179 @@ func LoginUser(client *mongo.Client) gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
 		defer cancel()
 
+		// reserves attempt before password is compared, so that parallel requests can not get around wait and lockout
+		ip := c.ClientIP()
+		decision, err := guard.Reserve(ctx, userLogin.Email, ip)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
+			return
+		}
+		if !decision.Allowed {
+			tooManyRequests(c, decision.RetryAfter, "Too many failed login attempts, try again later")
+			return
+		}
+
 		// opens user collection from database
 		var userCollection *mongo.Collection = database.OpenCollection("users", client)
 
-		// checks if user exists in database
+		// checks if user exists in database, failures of unknown emails are counted too so that lockout does not reveal which accounts exist
 		var foundUser models.User
-		err := userCollection.FindOne(ctx, bson.D{{Key: "email", Value: userLogin.Email}}).Decode(&foundUser)
+		err = userCollection.FindOne(ctx, bson.D{{Key: "email", Value: userLogin.Email}}).Decode(&foundUser)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				if err := guard.Cancel(ctx, userLogin.Email, ip); err != nil {
+					log.Println("Warning: unable to release login attempt:", err)
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find user"})
+				return
+			}
+			loginFailed(ctx, c, guard, userLogin.Email, ip, "")
 			return
 		}
 
 		// compares password with hashed password
 		err = bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(userLogin.Password))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+			loginFailed(ctx, c, guard, userLogin.Email, ip, foundUser.UserID)
 			return
 		}
 
-		// generates token and refresh token
-		token, refreshToken, err := utils.GenerateAllTokens(foundUser.Email, foundUser.FirstName, foundUser.LastName, foundUser.Role, foundUser.UserID)
+		// forgets failed attempts of account and takes back reserved attempt
+		if err := guard.Success(ctx, userLogin.Email, ip, foundUser.UserID); err != nil {
+			log.Println("Warning: unable to reset failed login attempts:", err)
+		}
+
+		// starts session of device and generates its token and refresh token
+		token, refreshToken, err := utils.CreateSession(ctx, client, c, foundUser)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate tokens"})
 			return
 		}
 
-		// updates tokens in database
-		err = utils.UpdateAllTokens(foundUser.UserID, token, refreshToken, client)
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tokens"})
-			return
+		// defines response with user data
+		response := models.UserResponse{
+			UserId:          foundUser.UserID,
+			FirstName:       foundUser.FirstName,
+			LastName:        foundUser.LastName,
+			Email:           foundUser.Email,
+			Role:            foundUser.Role,
+			FavouriteGenres: foundUser.FavouriteGenres,
+			EmailVerified:   foundUser.EmailVerified,
 		}
 
-		// sets access_token cookie
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:  "access_token",
-			Value: token,
-			Path:  "/",
-			// Domain:   "localhost",
-			MaxAge:   86400,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
-		// sets refresh_token cookie
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:  "refresh_token",
-			Value: refreshToken,
-			Path:  "/",
-			// Domain:   "localhost",
-			MaxAge:   604800,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
+		// non-browser clients get tokens in response body, browsers get them in http only cookies
+		if tokensInBody(c) {
+			response.Token = token
+			response.RefreshToken = refreshToken
+		} else {
+			setAuthCookies(c, token, refreshToken)
+		}
 
 		// uses context to write json response with user data
-		c.JSON(http.StatusOK, models.UserResponse{
-			UserId:    foundUser.UserID,
-			FirstName: foundUser.FirstName,
-			LastName:  foundUser.LastName,
-			Email:     foundUser.Email,
-			Role:      foundUser.Role,
-			//Token:           token,
-			//RefreshToken:    refreshToken,
-			FavouriteGenres: foundUser.FavouriteGenres,
-		})
+		c.JSON(http.StatusOK, response)
+
+	}
+}
 
+// creates function that records failed login and writes response, client is told to wait when failure locked account or ip
+func loginFailed(ctx context.Context, c *gin.Context, guard *loginguard.Guard, email, ip, userId string) {
+	if err := guard.Failure(ctx, email, ip, userId); err != nil {
+		log.Println("Warning: unable to record failed login attempt:", err)
+	}
+	if decision, err := guard.Check(ctx, email, ip); err == nil && !decision.Allowed && decision.Reason != "backoff" {
+		tooManyRequests(c, decision.RetryAfter, "Too many failed login attempts, try again later")
+		return
 	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 }
 
-// creates function that handles post request to /logout-user endpoint
+// creates function that writes 429 response with Retry-After header
+func tooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after_seconds": seconds})
+}
+
+// creates function that handles post request to /logout endpoint, user and session are taken from presented access or refresh token,
+// only that session is revoked unless client asks to log out everywhere
 func LogoutHandler(client *mongo.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
-		// defines userLogout model struct
-		var UserLogout struct {
-			UserId string `json:"user_id"`
+		// defines logout request struct, body is optional so binding error is ignored
+		var req struct {
+			RefreshToken string `json:"refresh_token"`
+			Everywhere   bool   `json:"everywhere"`
 		}
-
-		// uses ShouldBindJSON function to bind json request body to userLogout struct
-		err := c.ShouldBindJSON(&UserLogout)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
-			return
+		_ = c.ShouldBindJSON(&req)
+		everywhere := req.Everywhere
+		if value, err := strconv.ParseBool(c.Query("everywhere")); err == nil {
+			everywhere = everywhere || value
 		}
 
-		fmt.Println("User ID from Logout request:", UserLogout.UserId)
+		// uses special context to cancel request if timeout occurs
+		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
+		defer cancel()
 
-		// Clears all tokens for the user
-		err = utils.UpdateAllTokens(UserLogout.UserId, "", "", client)
+		// gets claims from access token, falls back to refresh token because access token may already have expired
+		var claims *utils.SignedDetails
+		var accessClaims *utils.SignedDetails
+		presented := false
+		if token, err := utils.GetAccessToken(c); err == nil && token != "" {
+			presented = true
+			if validated, err := utils.ValidateToken(token); err == nil {
+				claims, accessClaims = validated, validated
+			}
+		}
+		if claims == nil {
+			refreshToken := req.RefreshToken
+			if refreshToken == "" {
+				refreshToken, _ = c.Cookie("refresh_token")
+			}
+			if refreshToken != "" {
+				presented = true
+			}
+			if validated, err := utils.ValidateRefreshToken(refreshToken); err == nil {
+				claims = validated
+			}
+		}
 
-		// Optionally, you can also remove the user session from the database if needed
+		// clears cookies in any case, so that browser does not keep tokens that do not work
+		clearAuthCookies(c)
+		if !presented {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Access or refresh token is required to log out"})
+			return
+		}
+		if claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Valid access or refresh token is required to log out"})
+			return
+		}
+
+		// revokes access token that request was made with, so that copies of it stop working
+		if accessClaims != nil {
+			if err := utils.RevokeToken(ctx, client, accessClaims); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging out"})
+				return
+			}
+		}
 
+		// revokes session of token, or every session of user when logging out everywhere
+		filter := bson.M{"user_id": claims.UserId}
+		if !everywhere {
+			sessionId, err := bson.ObjectIDFromHex(claims.SessionId)
+			if err != nil {
+				c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully", "revoked_sessions": 0})
+				return
+			}
+			filter["_id"] = sessionId
+		}
+		revoked, err := utils.RevokeSessions(ctx, client, filter, models.SessionRevokedLogout)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error logging out"})
 			return
 		}
-		// c.SetCookie(
-		// 	"access_token",
-		// 	"",
-		// 	-1, // MaxAge negative → delete immediately
-		// 	"/",
-		// 	"localhost", // Adjust to your domain
-		// 	true,        // Use true in production with HTTPS
-		// 	true,        // HttpOnly
-		// )
-
-		// sets access token in cookie
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:  "access_token",
-			Value: "",
-			Path:  "/",
-			// Domain:   "localhost",
-			MaxAge:   -1,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
-
-		// // Clear the refresh_token cookie
-		// c.SetCookie(
-		// 	"refresh_token",
-		// 	"",
-		// 	-1,
-		// 	"/",
-		// 	"localhost",
-		// 	true,
-		// 	true,
-		// )
-
-		// sets refresh token in cookie
-		http.SetCookie(c.Writer, &http.Cookie{
-			Name:     "refresh_token",
-			Value:    "",
-			Path:     "/",
-			MaxAge:   -1,
-			Secure:   true,
-			HttpOnly: true,
-			SameSite: http.SameSiteNoneMode,
-		})
 
-		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully", "revoked_sessions": revoked})
 	}
 }
 
@@ -272,13 +332,23 @@ func RefreshTokenHandler(client *mongo.Client) gin.HandlerFunc {
 		var ctx, cancel = context.WithTimeout(c, 100*time.Second)
 		defer cancel()
 
-		// gets refresh token from cookie
-		refreshToken, err := c.Cookie("refresh_token")
-
-		if err != nil {
-			fmt.Println("error", err.Error())
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unable to retrieve refresh token from cookie"})
-			return
+		// gets refresh token from request body, non-browser clients send it there, body is optional so binding error is ignored
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		_ = c.ShouldBindJSON(&body)
+		refreshToken := body.RefreshToken
+		inBody := tokensInBody(c) || refreshToken != ""
+
+		// otherwise gets refresh token from cookie
+		if refreshToken == "" {
+			cookie, err := c.Cookie("refresh_token")
+			if err != nil {
+				fmt.Println("error", err.Error())
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to retrieve refresh token from request body or cookie"})
+				return
+			}
+			refreshToken = cookie
 		}
 
 		// defines claim using ValidateRefreshToken function
@@ -289,30 +359,75 @@ func RefreshTokenHandler(client *mongo.Client) gin.HandlerFunc {
 			return
 		}
 
-		// gets user collection from database
-		var userCollection *mongo.Collection = database.OpenCollection("users", client)
-
-		// defines filter based on user id
-		var user models.User
-		err = userCollection.FindOne(ctx, bson.D{{Key: "user_id", Value: claim.UserId}}).Decode(&user)
-
+		// swaps refresh token for new tokens of the same session
+		newToken, newRefreshToken, err := utils.RotateSession(ctx, client, claim, refreshToken)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			switch {
+			case errors.Is(err, utils.ErrRefreshTokenReused), errors.Is(err, utils.ErrSessionRevoked), errors.Is(err, utils.ErrSessionNotFound), errors.Is(err, utils.ErrSessionUserNotFound):
+				if !inBody {
+					clearAuthCookies(c)
+				}
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating tokens"})
+			}
 			return
 		}
 
-		// generates new tokens based on user data
-		newToken, newRefreshToken, _ := utils.GenerateAllTokens(user.Email, user.FirstName, user.LastName, user.Role, user.UserID)
-		err = utils.UpdateAllTokens(user.UserID, newToken, newRefreshToken, client)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating tokens"})
+		// sends new tokens the same way client sent refresh token
+		if inBody {
+			c.JSON(http.StatusOK, gin.H{"message": "Tokens refreshed", "token": newToken, "refresh_token": newRefreshToken})
 			return
 		}
-
-		// sets cookies with new tokens
-		c.SetCookie("access_token", newToken, 86400, "/", "localhost", true, true)          // expires in 24 hours
-		c.SetCookie("refresh_token", newRefreshToken, 604800, "/", "localhost", true, true) //expires in 1 week
+		setAuthCookies(c, newToken, newRefreshToken)
 
 		c.JSON(http.StatusOK, gin.H{"message": "Tokens refreshed"})
 	}
 }
+
+// defines header that non-browser clients send with value "body" to get tokens in response body instead of cookies
+const tokenDeliveryHeader = "X-Token-Delivery"
+
+// creates function that checks if client asked for tokens in response body
+func tokensInBody(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(tokenDeliveryHeader), "body")
+}
+
+// creates function that sets access_token and refresh_token cookies
+func setAuthCookies(c *gin.Context, token, refreshToken string) {
+	// sets access_token cookie
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "access_token",
+		Value:    token,
+		Path:     "/",
+		MaxAge:   86400,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+	// sets refresh_token cookie
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Path:     "/",
+		MaxAge:   int(utils.RefreshTokenTTL.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
+
+// creates function that removes access_token and refresh_token cookies
+func clearAuthCookies(c *gin.Context) {
+	for _, name := range []string{"access_token", "refresh_token"} {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			MaxAge:   -1,
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteNoneMode,
+		})
+	}
+}
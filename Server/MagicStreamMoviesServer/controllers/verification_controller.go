@@ -0,0 +1,136 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/mailer"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines default email verification settings
+const (
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultVerifyEmailURL       = "http://localhost:8080/verify-email"
+)
+
+// creates function that handles get request to /verify-email endpoint that is opened from link in verification email
+func VerifyEmail(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// validates token from query
+		claims, err := utils.ValidateEmailVerificationToken(c.Query("token"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// marks email as verified, email must still be the one link was sent to
+		now := time.Now()
+		result, err := database.OpenCollection("users", client).UpdateOne(ctx,
+			bson.M{"user_id": claims.UserId, "email": claims.Email, "email_verified": bson.M{"$ne": true}},
+			bson.M{"$set": bson.M{"email_verified": true, "email_verified_at": now, "update_at": now}},
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while verifying email"})
+			return
+		}
+		if result.MatchedCount == 0 {
+			// link may be opened twice, second time email is already verified
+			count, err := database.OpenCollection("users", client).CountDocuments(ctx,
+				bson.M{"user_id": claims.UserId, "email": claims.Email, "email_verified": true})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while verifying email"})
+				return
+			}
+			if count == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired verification link"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"message": "Email is already verified"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+	}
+}
+
+// creates function that handles post request to /verify-email/resend endpoint to send new verification email to logged in user
+func ResendVerificationEmail(client *mongo.Client, mail mailer.Mailer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// finds user by id
+		var user models.User
+		if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": userId}).Decode(&user); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching user"})
+			return
+		}
+		if user.EmailVerified {
+			c.JSON(http.StatusOK, gin.H{"message": "Email is already verified"})
+			return
+		}
+
+		// sends verification email
+		if err := sendVerificationEmail(ctx, mail, user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+	}
+}
+
+// creates function that sends email with signed verification link to user
+func sendVerificationEmail(ctx context.Context, mail mailer.Mailer, user models.User) error {
+	// generates signed token
+	ttl := emailVerificationTTL()
+	token, err := utils.GenerateEmailVerificationToken(user.UserID, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	return mail.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your MagicStream email",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"Please confirm your email address by opening the link below. It expires in " + ttl.String() + ".\n\n" +
+			linkWithToken(os.Getenv("VERIFY_EMAIL_URL"), defaultVerifyEmailURL, token) + "\n\n" +
+			"If you did not create a MagicStream account, you can ignore this email.\n",
+	})
+}
+
+// creates function that gets how long email verification link works, can be set in hours with EMAIL_VERIFICATION_TTL_HOURS env
+func emailVerificationTTL() time.Duration {
+	if hours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TTL_HOURS")); err == nil && hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultEmailVerificationTTL
+}
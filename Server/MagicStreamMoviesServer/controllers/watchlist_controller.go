@@ -0,0 +1,169 @@
+// marks file as part of controllers package
+package controllers
+
+// imports packages
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// creates function that handles get request to /watchlist endpoint to get movies saved by user
+func GetWatchlist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// reads pagination from query parameters
+		pagination, err := utils.GetPagination(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// opens watchlists collection from database
+		var watchlistCollection *mongo.Collection = database.OpenCollection("watchlists", client)
+
+		// counts movies saved by user
+		totalCount, err := watchlistCollection.CountDocuments(ctx, bson.M{"user_id": userId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while counting watchlist movies"})
+			return
+		}
+
+		// defines pipeline that gets page of watchlist and joins movie documents
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"user_id": userId}}},
+			{{Key: "$sort", Value: bson.D{{Key: "added_at", Value: -1}, {Key: "_id", Value: -1}}}},
+			{{Key: "$skip", Value: pagination.Skip()}},
+			{{Key: "$limit", Value: pagination.Limit}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":         "movies",
+				"localField":   "imdb_id",
+				"foreignField": "imdb_id",
+				"as":           "movie",
+			}}},
+			{{Key: "$unwind", Value: "$movie"}},
+			{{Key: "$project", Value: bson.M{"_id": 0, "movie": 1, "added_at": 1}}},
+		}
+
+		// defines cursor
+		cursor, err := watchlistCollection.Aggregate(ctx, pipeline)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching watchlist from database"})
+			return
+		}
+		// closes cursor after function ends(in any case)
+		defer cursor.Close(ctx)
+
+		// passes cursor to items variable
+		items := []models.WatchlistEntry{}
+		if err := cursor.All(ctx, &items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decode watchlist from database"})
+			return
+		}
+
+		// uses context to write json response with watchlist page
+		c.JSON(http.StatusOK, models.WatchlistPage{
+			Items:        items,
+			PageMetadata: utils.BuildPageMetadata(c, pagination, totalCount),
+		})
+	}
+}
+
+// creates function that handles post request to /watchlist/:imdb_id endpoint to save movie to user watchlist
+func AddToWatchlist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets movie id from url parameter
+		movieId := c.Param("imdb_id")
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// checks if movie exists
+		count, err := database.OpenCollection("movies", client).CountDocuments(ctx, bson.M{"imdb_id": movieId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching movie from database"})
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+			return
+		}
+
+		// saves movie to watchlist, saving the same movie twice keeps time when it was saved first
+		var watchlistCollection *mongo.Collection = database.OpenCollection("watchlists", client)
+		result, err := watchlistCollection.UpdateOne(ctx,
+			bson.M{"user_id": userId, "imdb_id": movieId},
+			bson.M{"$setOnInsert": models.WatchlistItem{UserID: userId, ImdbID: movieId, AddedAt: time.Now()}},
+			options.UpdateOne().SetUpsert(true),
+		)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while adding movie to watchlist"})
+			return
+		}
+
+		// movie was already in watchlist
+		if result.UpsertedCount == 0 {
+			c.JSON(http.StatusOK, gin.H{"message": "Movie is already in watchlist"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Movie added to watchlist"})
+	}
+}
+
+// creates function that handles delete request to /watchlist/:imdb_id endpoint to remove movie from user watchlist
+func RemoveFromWatchlist(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// extracts user id using GetUserIdFromContext function
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		// gets movie id from url parameter
+		movieId := c.Param("imdb_id")
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// removes movie from watchlist
+		var watchlistCollection *mongo.Collection = database.OpenCollection("watchlists", client)
+		result, err := watchlistCollection.DeleteOne(ctx, bson.M{"user_id": userId, "imdb_id": movieId})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while removing movie from watchlist"})
+			return
+		}
+		if result.DeletedCount == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Movie is not in watchlist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Movie removed from watchlist"})
+	}
+}
@@ -0,0 +1,282 @@
+// marks file as part of database package
+package database
+
+// imports packages
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines how long audit events are kept by default
+const defaultAuditRetention = 90 * 24 * time.Hour
+
+// creates function that creates indexes needed by application, should be called once at startup
+func CreateIndexes(client *mongo.Client) error {
+	// creates special context that cancels request if timeout occurs
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	// cancels request when function ends(to prevent memory leaks)
+	defer cancel()
+
+	// opens movies collection from database
+	movieCollection := OpenCollection("movies", client)
+
+	// creates text index over movie title and admin review used by movie search, title matches weigh more than review matches
+	_, err := movieCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "title", Value: "text"},
+			{Key: "admin_review", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("movie_text_search").
+			SetWeights(bson.D{
+				{Key: "title", Value: 10},
+				{Key: "admin_review", Value: 2},
+			}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on movie imdb id, so that the same movie can not be added twice
+	_, err = movieCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "imdb_id", Value: 1}},
+		Options: options.Index().SetName("movie_imdb_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates index on movie title and imdb id, typo tolerant search scans its keys instead of movie documents
+	_, err = movieCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "title", Value: 1}, {Key: "imdb_id", Value: 1}},
+		Options: options.Index().SetName("movie_title_imdb_id"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates index on movie weighted audience score used to sort movies and recommendation candidates by it
+	_, err = movieCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "audience_score.weighted_score", Value: -1}, {Key: "imdb_id", Value: 1}},
+		Options: options.Index().SetName("movie_weighted_score_imdb_id"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// opens idempotency keys collection from database
+	idempotencyCollection := OpenCollection("idempotency_keys", client)
+
+	// creates unique index on idempotency key and index that removes stored keys after one day
+	_, err = idempotencyCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("idempotency_key_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("idempotency_key_ttl").SetExpireAfterSeconds(24 * 60 * 60),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates index on review job status used to requeue pending jobs at startup
+	_, err = OpenCollection("review_jobs", client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "status", Value: 1}},
+		Options: options.Index().SetName("review_job_status"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on user id and movie id, so that movie is saved to user watchlist only once, and index used to list watchlist
+	_, err = OpenCollection("watchlists", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}},
+			Options: options.Index().SetName("watchlist_user_movie_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "added_at", Value: -1}},
+			Options: options.Index().SetName("watchlist_user_added_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "imdb_id", Value: 1}},
+			Options: options.Index().SetName("watchlist_movie"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on user id and movie id, so that user rates movie only once
+	_, err = OpenCollection("ratings", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}},
+			Options: options.Index().SetName("rating_user_movie_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "imdb_id", Value: 1}},
+			Options: options.Index().SetName("rating_movie"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on movie id and user id, so that user writes one review per movie, and index used to list movie reviews
+	_, err = OpenCollection("reviews", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "imdb_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("review_movie_user_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "imdb_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("review_movie_created_at"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on user id and movie id, so that user has one resume point per movie, and index used to list history
+	_, err = OpenCollection("viewing_history", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}},
+			Options: options.Index().SetName("viewing_history_user_movie_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "last_watched_at", Value: -1}},
+			Options: options.Index().SetName("viewing_history_user_last_watched_at"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on movie id, so that every movie has one embedding
+	_, err = OpenCollection("movie_embeddings", client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "imdb_id", Value: 1}},
+		Options: options.Index().SetName("movie_embeddings_imdb_id_unique").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates unique index on hash of password reset token and ttl index that removes expired tokens
+	_, err = OpenCollection("password_resets", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetName("password_resets_token_hash_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("password_resets_expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("password_resets_user_id"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates index on user id, users are looked up by it on every request when email verification is required
+	_, err = OpenCollection("users", client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}},
+		Options: options.Index().SetName("users_user_id"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates index used to list sessions of user and ttl index that removes expired sessions, revoked sessions are kept until they expire
+	// so that reuse of their refresh tokens is still detected
+	_, err = OpenCollection("sessions", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "last_used_at", Value: -1}},
+			Options: options.Index().SetName("sessions_user_last_used_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("sessions_expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates ttl index that removes denylist entries once tokens they cover have expired
+	_, err = OpenCollection("revoked_tokens", client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("revoked_tokens_expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates ttl index that removes counters of failed logins once they are forgotten
+	_, err = OpenCollection("login_attempts", client).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("login_attempts_expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates indexes used to list audit events of account and of one type
+	_, err = OpenCollection("audit_events", client).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("audit_events_email_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("audit_events_type_created_at"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// creates ttl index that removes audit events once they are older than retention, every failed login adds event, so without it
+	// any client could grow collection without limit
+	auditCollection := OpenCollection("audit_events", client)
+	retention := int32(auditRetention() / time.Second)
+	_, err = auditCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("audit_events_created_at_ttl").SetExpireAfterSeconds(retention),
+	})
+	if err != nil {
+		return err
+	}
+	// updates retention of index created earlier, creating index again does not change it
+	err = auditCollection.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: auditCollection.Name()},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: "audit_events_created_at_ttl"},
+			{Key: "expireAfterSeconds", Value: retention},
+		}},
+	}).Err()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// creates function that gets how long audit events are kept, can be set in days with AUDIT_RETENTION_DAYS env
+func auditRetention() time.Duration {
+	if days, err := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS")); err == nil && days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return defaultAuditRetention
+}
@@ -0,0 +1,142 @@
+// marks file as part of database package
+package database
+
+// imports packages
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// creates function that updates documents stored by older versions of application, should be called once at startup before CreateIndexes
+// because indexes rely on migrated data, every step can run again safely, rating prior mean is weighted score of movie without ratings
+func RunMigrations(client *mongo.Client, ratingPriorMean float64) error {
+	// creates special context that cancels request if timeout occurs
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+	// cancels request when function ends(to prevent memory leaks)
+	defer cancel()
+
+	// removes movies added more than once before unique index on imdb id existed, otherwise the index can not be created
+	if err := removeDuplicateMovies(ctx, client); err != nil {
+		return err
+	}
+
+	// sets audience score of movies without ratings to prior mean, movies stored before ratings were added have no audience score
+	// and new ones used to get zero, so they were sorted below badly rated movies, it also follows change of RATING_PRIOR_MEAN
+	result, err := OpenCollection("movies", client).UpdateMany(ctx,
+		bson.M{
+			"$or": bson.A{
+				bson.M{"audience_score.count": bson.M{"$exists": false}},
+				bson.M{"audience_score.count": 0},
+			},
+			"audience_score.weighted_score": bson.M{"$ne": ratingPriorMean},
+		},
+		bson.M{"$set": bson.M{"audience_score": bson.M{"count": 0, "sum": 0, "mean": 0, "weighted_score": ratingPriorMean}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Set audience score of %d movies without ratings to prior mean", result.ModifiedCount)
+	}
+
+	// marks users created before email verification was added as verified, so that REQUIRE_EMAIL_VERIFICATION does not lock them out,
+	// users registered since then always have email_verified field and are not touched
+	result, err = OpenCollection("users", client).UpdateMany(ctx,
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Marked %d existing users as having verified email", result.ModifiedCount)
+	}
+
+	return nil
+}
+
+// creates function that keeps the oldest movie of every imdb id and moves the other copies to movie_duplicates collection,
+// so that admin can still look at them, ratings, reviews, watchlists and history point to movie by imdb id and stay with kept movie,
+// kept movie takes audience score of the copy that counted the most ratings
+func removeDuplicateMovies(ctx context.Context, client *mongo.Client) error {
+	movieCollection := OpenCollection("movies", client)
+	duplicateCollection := OpenCollection("movie_duplicates", client)
+
+	// finds imdb ids stored more than once, ids of their movies are ordered from the oldest
+	cursor, err := movieCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"imdb_id": bson.M{"$type": "string"}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{"_id": "$imdb_id", "ids": bson.M{"$push": "$_id"}, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	var groups []struct {
+		ImdbID string          `bson:"_id"`
+		IDs    []bson.ObjectID `bson:"ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		keptId := group.IDs[0]
+
+		// loads copies of movie
+		cursor, err := movieCollection.Find(ctx, bson.M{"_id": bson.M{"$in": group.IDs}})
+		if err != nil {
+			return err
+		}
+		var copies []bson.Raw
+		if err := cursor.All(ctx, &copies); err != nil {
+			return err
+		}
+
+		// keeps audience score of copy with the most ratings
+		var bestScore bson.RawValue
+		bestCount := int64(-1)
+		for _, movie := range copies {
+			score := movie.Lookup("audience_score")
+			if score.IsZero() {
+				continue
+			}
+			if count, _ := movie.Lookup("audience_score", "count").AsInt64OK(); count > bestCount {
+				bestCount, bestScore = count, score
+			}
+		}
+		if !bestScore.IsZero() {
+			if _, err := movieCollection.UpdateOne(ctx, bson.M{"_id": keptId}, bson.M{"$set": bson.M{"audience_score": bestScore}}); err != nil {
+				return err
+			}
+		}
+
+		// moves other copies away, copy is stored first so that it is not lost if deleting fails
+		for _, movie := range copies {
+			id, _ := movie.Lookup("_id").ObjectIDOK()
+			if id == keptId {
+				continue
+			}
+			var duplicate bson.D
+			if err := bson.Unmarshal(movie, &duplicate); err != nil {
+				return err
+			}
+			duplicate = append(duplicate, bson.E{Key: "duplicate_of", Value: keptId}, bson.E{Key: "removed_at", Value: time.Now()})
+			if _, err := duplicateCollection.ReplaceOne(ctx, bson.M{"_id": id}, duplicate, options.Replace().SetUpsert(true)); err != nil {
+				return err
+			}
+			if _, err := movieCollection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+				return err
+			}
+		}
+		log.Printf("Removed %d duplicates of movie %s, kept %s, removed copies are in movie_duplicates collection",
+			len(group.IDs)-1, group.ImdbID, keptId.Hex())
+	}
+
+	return nil
+}
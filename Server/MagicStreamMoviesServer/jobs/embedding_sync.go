@@ -0,0 +1,95 @@
+// marks file as part of jobs package
+package jobs
+
+// imports packages
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/recommender"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines default settings of embedding sync
+const (
+	defaultEmbeddingSyncInterval = 10 * time.Minute
+	embeddingSyncTimeout         = 10 * time.Minute
+)
+
+// defines EmbeddingSync that builds movie embeddings in background, so that similar movies requests only read stored vectors,
+// one goroutine runs all syncs, so that concurrent movie writes never embed the same movie twice
+type EmbeddingSync struct {
+	client   *mongo.Client
+	embedder llm.Embedder
+	interval time.Duration
+
+	trigger chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// creates function that builds embedding sync, interval between full syncs can be set with EMBEDDING_SYNC_MINUTES env
+func NewEmbeddingSync(client *mongo.Client, embedder llm.Embedder) *EmbeddingSync {
+	interval := defaultEmbeddingSyncInterval
+	if minutes, err := strconv.Atoi(os.Getenv("EMBEDDING_SYNC_MINUTES")); err == nil && minutes > 0 {
+		interval = time.Duration(minutes) * time.Minute
+	}
+	return &EmbeddingSync{
+		client:   client,
+		embedder: embedder,
+		interval: interval,
+		trigger:  make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// creates method that starts sync loop, embeddings are synced at start, after movie writes and every interval
+func (s *EmbeddingSync) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.sync()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			case <-s.trigger:
+			}
+		}
+	}()
+}
+
+// creates method that stops sync loop
+func (s *EmbeddingSync) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// creates method that asks for sync after movie was added or changed, it never blocks caller and several calls are merged into one sync
+func (s *EmbeddingSync) Notify() {
+	if s == nil {
+		return
+	}
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// creates method that builds missing and stale embeddings
+func (s *EmbeddingSync) sync() {
+	ctx, cancel := context.WithTimeout(context.Background(), embeddingSyncTimeout)
+	defer cancel()
+
+	if err := recommender.SyncEmbeddings(ctx, s.client, s.embedder); err != nil {
+		log.Println("Warning: unable to sync movie embeddings:", err)
+	}
+}
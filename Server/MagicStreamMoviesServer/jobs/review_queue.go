@@ -0,0 +1,400 @@
+// marks file as part of jobs package
+package jobs
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines default settings of review queue
+const (
+	defaultMaxAttempts     = 5
+	defaultBaseBackoff     = 2 * time.Second
+	defaultMaxBackoff      = 2 * time.Minute
+	defaultClassifyTimeout = 60 * time.Second
+	// claimDuration is how long claimed job is kept from other workers, it is longer than processing of job may take
+	claimDuration   = defaultClassifyTimeout + 30*time.Second
+	queueBufferSize = 1000
+)
+
+// defines error of job whose review was changed again after job was created
+var errReviewSuperseded = errors.New("review was changed after job was created")
+
+// defines ReviewQueue that classifies reviews in background using pool of workers
+type ReviewQueue struct {
+	client      *mongo.Client
+	classifier  llm.RankingClassifier
+	fallback    llm.RankingClassifier
+	workers     int
+	maxAttempts int
+	baseBackoff time.Duration
+
+	jobs chan bson.ObjectID
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	mu          sync.Mutex
+	subscribers map[bson.ObjectID][]chan models.ReviewJob
+}
+
+// creates function that builds review queue with given number of workers, errors of classifier are retried with backoff
+// and fallback classifier (can be nil) is used only after the last attempt fails
+func NewReviewQueue(client *mongo.Client, classifier, fallback llm.RankingClassifier, workers int) *ReviewQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &ReviewQueue{
+		client:      client,
+		classifier:  classifier,
+		fallback:    fallback,
+		workers:     workers,
+		maxAttempts: defaultMaxAttempts,
+		baseBackoff: defaultBaseBackoff,
+		jobs:        make(chan bson.ObjectID, queueBufferSize),
+		stop:        make(chan struct{}),
+		subscribers: map[bson.ObjectID][]chan models.ReviewJob{},
+	}
+}
+
+// creates method that starts workers and requeues jobs that were left pending by previous run
+func (q *ReviewQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+
+	// requeues pending jobs in background
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		cursor, err := q.collection().Find(ctx, bson.M{"status": models.ReviewStatusPending})
+		if err != nil {
+			log.Println("Warning: unable to load pending review jobs:", err)
+			return
+		}
+		var pending []models.ReviewJob
+		if err := cursor.All(ctx, &pending); err != nil {
+			log.Println("Warning: unable to decode pending review jobs:", err)
+			return
+		}
+		for _, job := range pending {
+			// job claimed by other worker or instance is tried again once its claim runs out, in case that instance stopped
+			if job.LockedUntil != nil && job.LockedUntil.After(time.Now()) {
+				jobID := job.ID
+				time.AfterFunc(time.Until(*job.LockedUntil), func() { q.push(jobID) })
+				continue
+			}
+			q.push(job.ID)
+		}
+	}()
+}
+
+// creates method that stops workers, jobs that are still pending are picked up on next start
+func (q *ReviewQueue) Stop() {
+	close(q.stop)
+	q.wg.Wait()
+}
+
+// creates function that builds new pending job of review written by user, job must be passed to Enqueue after reviewed document references its id
+func NewReviewJob(kind, targetID, review, userId string) models.ReviewJob {
+	now := time.Now()
+	return models.ReviewJob{
+		ID:        bson.NewObjectID(),
+		Kind:      kind,
+		TargetID:  targetID,
+		UserID:    userId,
+		Review:    review,
+		Status:    models.ReviewStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// creates method that stores pending job and puts it to queue
+func (q *ReviewQueue) Enqueue(ctx context.Context, job models.ReviewJob) error {
+	// stores job, so that it survives restarts
+	if _, err := q.collection().InsertOne(ctx, job); err != nil {
+		return err
+	}
+
+	q.push(job.ID)
+	return nil
+}
+
+// creates method that gets job by id
+func (q *ReviewQueue) GetJob(ctx context.Context, jobID bson.ObjectID) (models.ReviewJob, error) {
+	var job models.ReviewJob
+	err := q.collection().FindOne(ctx, bson.M{"_id": jobID}).Decode(&job)
+	return job, err
+}
+
+// creates method that subscribes to job completion, returned channel receives job once it is ranked or failed, returned function must be called to unsubscribe
+func (q *ReviewQueue) Subscribe(jobID bson.ObjectID) (<-chan models.ReviewJob, func()) {
+	ch := make(chan models.ReviewJob, 1)
+
+	q.mu.Lock()
+	q.subscribers[jobID] = append(q.subscribers[jobID], ch)
+	q.mu.Unlock()
+
+	// defines function that removes subscription
+	unsubscribe := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		subscribers := q.subscribers[jobID]
+		for i, subscriber := range subscribers {
+			if subscriber == ch {
+				q.subscribers[jobID] = append(subscribers[:i], subscribers[i+1:]...)
+				break
+			}
+		}
+		if len(q.subscribers[jobID]) == 0 {
+			delete(q.subscribers, jobID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// creates method that puts job id to queue without blocking caller
+func (q *ReviewQueue) push(jobID bson.ObjectID) {
+	select {
+	case q.jobs <- jobID:
+	default:
+		// queue is full, waits for free place in background
+		go func() {
+			select {
+			case q.jobs <- jobID:
+			case <-q.stop:
+			}
+		}()
+	}
+}
+
+// creates method that runs worker loop
+func (q *ReviewQueue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		case jobID := <-q.jobs:
+			q.process(jobID)
+		}
+	}
+}
+
+// creates method that classifies review of job, schedules retry with exponential backoff on failure
+func (q *ReviewQueue) process(jobID bson.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultClassifyTimeout)
+	defer cancel()
+
+	// claims job, skips jobs that are already finished or are processed by other worker or server instance
+	job, err := q.claim(ctx, jobID)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Warning: unable to claim review job %s: %v", jobID.Hex(), err)
+		}
+		return
+	}
+
+	// classifies review and applies ranking
+	ranking, err := q.classify(ctx, q.classifier, job)
+	if err == nil {
+		err = q.apply(ctx, job, ranking)
+	}
+
+	job.Attempts++
+	job.UpdatedAt = time.Now()
+
+	if err == nil {
+		job.Status = models.ReviewStatusRanked
+		job.Ranking = &ranking
+		job.LastError = ""
+		q.finish(ctx, job)
+		return
+	}
+
+	// review was changed again, newer job ranks it
+	if errors.Is(err, errReviewSuperseded) {
+		job.Status = models.ReviewStatusSuperseded
+		job.LastError = ""
+		q.finish(ctx, job)
+		return
+	}
+
+	log.Printf("Warning: review job %s attempt %d failed: %v", job.ID.Hex(), job.Attempts, err)
+	job.LastError = err.Error()
+
+	// ranks review with fallback classifier after last attempt, gives up when it fails too
+	if job.Attempts >= q.maxAttempts && q.fallback != nil {
+		if ranking, fallbackErr := q.classify(ctx, q.fallback, job); fallbackErr == nil {
+			fallbackErr = q.apply(ctx, job, ranking)
+			if fallbackErr == nil {
+				log.Printf("Warning: review job %s ranked by fallback classifier", job.ID.Hex())
+				job.Status = models.ReviewStatusRanked
+				job.Ranking = &ranking
+				job.LastError = "ranked by fallback classifier: " + job.LastError
+				q.finish(ctx, job)
+				return
+			}
+			if errors.Is(fallbackErr, errReviewSuperseded) {
+				job.Status = models.ReviewStatusSuperseded
+				job.LastError = ""
+				q.finish(ctx, job)
+				return
+			}
+		}
+	}
+	if job.Attempts >= q.maxAttempts {
+		job.Status = models.ReviewStatusFailed
+		q.markTargetFailed(ctx, job)
+		q.finish(ctx, job)
+		return
+	}
+
+	// stores attempt and schedules retry, job stays claimed until retry so that other instances do not run it earlier
+	delay := q.backoff(job.Attempts)
+	_, _ = q.collection().UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{
+		"attempts":     job.Attempts,
+		"last_error":   job.LastError,
+		"updated_at":   job.UpdatedAt,
+		"locked_until": job.UpdatedAt.Add(delay),
+	}})
+	time.AfterFunc(delay, func() { q.push(job.ID) })
+}
+
+// creates method that atomically claims pending job that is not claimed by anyone else, so that every attempt runs on one worker
+// of one server instance, mongo.ErrNoDocuments is returned when job is finished or claimed already
+func (q *ReviewQueue) claim(ctx context.Context, jobID bson.ObjectID) (models.ReviewJob, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    jobID,
+		"status": models.ReviewStatusPending,
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	var job models.ReviewJob
+	err := q.collection().FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"locked_until": now.Add(claimDuration)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	return job, err
+}
+
+// creates method that counts delay before next attempt, delay doubles after every attempt and has random jitter
+func (q *ReviewQueue) backoff(attempts int) time.Duration {
+	delay := q.baseBackoff << (attempts - 1)
+	if delay > defaultMaxBackoff || delay <= 0 {
+		delay = defaultMaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// creates method that classifies review of job with given classifier using rankings from database
+func (q *ReviewQueue) classify(ctx context.Context, classifier llm.RankingClassifier, job models.ReviewJob) (models.Ranking, error) {
+	// gets all rankings
+	var rankings []models.Ranking
+	cursor, err := database.OpenCollection("rankings", q.client).Find(ctx, bson.M{})
+	if err != nil {
+		return models.Ranking{}, err
+	}
+	if err := cursor.All(ctx, &rankings); err != nil {
+		return models.Ranking{}, err
+	}
+
+	return classifier.Classify(ctx, job.Review, rankings)
+}
+
+// creates method that saves ranking to reviewed document
+func (q *ReviewQueue) apply(ctx context.Context, job models.ReviewJob, ranking models.Ranking) error {
+	collection, filter, err := q.target(job)
+	if err != nil {
+		return err
+	}
+
+	// updates document only if review was not changed again after job was created
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"ranking":       ranking,
+		"review_status": models.ReviewStatusRanked,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errReviewSuperseded
+	}
+	return nil
+}
+
+// creates method that marks reviewed document as failed after last attempt
+func (q *ReviewQueue) markTargetFailed(ctx context.Context, job models.ReviewJob) {
+	collection, filter, err := q.target(job)
+	if err != nil {
+		return
+	}
+	_, _ = collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"review_status": models.ReviewStatusFailed}})
+}
+
+// creates method that gets collection and filter of document reviewed by job
+func (q *ReviewQueue) target(job models.ReviewJob) (*mongo.Collection, bson.M, error) {
+	switch job.Kind {
+	case models.ReviewKindAdmin:
+		// admin review is stored in movie document
+		return database.OpenCollection("movies", q.client), bson.M{"imdb_id": job.TargetID, "review_job_id": job.ID.Hex()}, nil
+	case models.ReviewKindUser:
+		// user review is stored in reviews collection
+		reviewID, err := bson.ObjectIDFromHex(job.TargetID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return database.OpenCollection("reviews", q.client), bson.M{"_id": reviewID, "review_job_id": job.ID.Hex()}, nil
+	default:
+		return nil, nil, errors.New("unknown review job kind " + job.Kind)
+	}
+}
+
+// creates method that stores finished job and notifies subscribers
+func (q *ReviewQueue) finish(ctx context.Context, job models.ReviewJob) {
+	_, err := q.collection().UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set": bson.M{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"last_error": job.LastError,
+			"ranking":    job.Ranking,
+			"updated_at": job.UpdatedAt,
+		},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	if err != nil {
+		log.Printf("Warning: unable to save review job %s: %v", job.ID.Hex(), err)
+	}
+
+	// notifies and removes subscribers
+	q.mu.Lock()
+	subscribers := q.subscribers[job.ID]
+	delete(q.subscribers, job.ID)
+	q.mu.Unlock()
+	for _, subscriber := range subscribers {
+		subscriber <- job
+	}
+}
+
+// creates method that opens review jobs collection
+func (q *ReviewQueue) collection() *mongo.Collection {
+	return database.OpenCollection("review_jobs", q.client)
+}
@@ -0,0 +1,178 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// defines name of local embedding provider
+const ProviderHashing = "hashing"
+
+// defines default embedding settings
+const (
+	defaultHashingDimensions    = 512
+	defaultOllamaEmbeddingModel = "nomic-embed-text"
+)
+
+// defines Embedder interface that turns texts into vectors, Name identifies provider and model so that vectors of different embedders are never compared
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// defines embeddingModel interface implemented by langchaingo openai and ollama models
+type embeddingModel interface {
+	CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// creates function that builds embedder configured with EMBEDDING_PROVIDER env, uses local hashing embedder when provider is not set
+func NewEmbedderFromEnv() (Embedder, error) {
+	// gets provider name from env
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("EMBEDDING_PROVIDER")))
+
+	switch provider {
+	case "", ProviderHashing:
+		return NewHashingEmbedder(defaultHashingDimensions), nil
+
+	case ProviderOpenAI:
+		// gets openai api key from env
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("could not read OPENAI_API_KEY")
+		}
+		// defines openai options, embedding model can be overridden with OPENAI_EMBEDDING_MODEL env
+		opts := []openai.Option{openai.WithToken(apiKey)}
+		modelName := os.Getenv("OPENAI_EMBEDDING_MODEL")
+		if modelName != "" {
+			opts = append(opts, openai.WithEmbeddingModel(modelName))
+		} else {
+			modelName = "default"
+		}
+		model, err := openai.New(opts...)
+		if err != nil {
+			return nil, err
+		}
+		return NewLLMEmbedder(ProviderOpenAI+":"+modelName, model), nil
+
+	case ProviderOllama:
+		// gets ollama server url and embedding model from env
+		serverURL := os.Getenv("OLLAMA_SERVER_URL")
+		if serverURL == "" {
+			serverURL = defaultOllamaServerURL
+		}
+		modelName := os.Getenv("OLLAMA_EMBEDDING_MODEL")
+		if modelName == "" {
+			modelName = defaultOllamaEmbeddingModel
+		}
+		model, err := ollama.New(ollama.WithServerURL(serverURL), ollama.WithModel(modelName))
+		if err != nil {
+			return nil, err
+		}
+		return NewLLMEmbedder(ProviderOllama+":"+modelName, model), nil
+
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q", provider)
+	}
+}
+
+// defines LLMEmbedder that gets vectors from embedding model of llm provider
+type LLMEmbedder struct {
+	name  string
+	model embeddingModel
+}
+
+// creates function that builds LLMEmbedder
+func NewLLMEmbedder(name string, model embeddingModel) *LLMEmbedder {
+	return &LLMEmbedder{name: name, model: model}
+}
+
+// creates method that gets name of embedder
+func (e *LLMEmbedder) Name() string {
+	return e.name
+}
+
+// creates method that embeds texts using llm provider
+func (e *LLMEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := e.model.CreateEmbedding(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// defines HashingEmbedder that builds vectors locally by hashing words and word pairs into fixed number of dimensions, it works offline and needs no training
+type HashingEmbedder struct {
+	dimensions int
+}
+
+// creates function that builds HashingEmbedder
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	return &HashingEmbedder{dimensions: dimensions}
+}
+
+// creates method that gets name of embedder
+func (e *HashingEmbedder) Name() string {
+	return fmt.Sprintf("%s:%d", ProviderHashing, e.dimensions)
+}
+
+// creates method that embeds texts by hashing their terms
+func (e *HashingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+// creates method that embeds one text, terms are counted with sublinear term frequency and vector is scaled to unit length
+func (e *HashingEmbedder) embed(text string) []float32 {
+	// counts words and pairs of neighbour words, pairs keep some of word order
+	counts := map[string]int{}
+	words := utils.Tokenize(text)
+	for i, word := range words {
+		counts[word]++
+		if i > 0 {
+			counts[words[i-1]+" "+word]++
+		}
+	}
+
+	// adds weight of every term to its dimension, sign hash keeps collisions from always adding up
+	vector := make([]float32, e.dimensions)
+	for term, count := range counts {
+		hash := fnv.New64a()
+		hash.Write([]byte(term))
+		sum := hash.Sum64()
+		weight := 1 + math.Log(float64(count))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		vector[sum%uint64(e.dimensions)] += float32(weight)
+	}
+
+	// scales vector to unit length
+	norm := 0.0
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+	return vector
+}
@@ -0,0 +1,73 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// creates function that counts dot product of two vectors, it is cosine similarity of unit vectors
+func dot(a, b []float32) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func TestHashingEmbedderBuildsUnitVectors(t *testing.T) {
+	embedder := NewHashingEmbedder(64)
+	texts := []string{
+		"The Matrix",
+		"Drama, Drama, Drama",
+		"A hacker learns that reality is a simulation run by machines and joins the rebels fighting them",
+	}
+
+	vectors, err := embedder.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed returned error: %v", err)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("Embed returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	for i, vector := range vectors {
+		if len(vector) != 64 {
+			t.Errorf("vector of %q has %d dimensions, want 64", texts[i], len(vector))
+		}
+		if norm := math.Sqrt(dot(vector, vector)); math.Abs(norm-1) > 1e-5 {
+			t.Errorf("vector of %q has length %v, want 1", texts[i], norm)
+		}
+	}
+}
+
+func TestHashingEmbedderSimilarity(t *testing.T) {
+	embedder := NewHashingEmbedder(512)
+
+	tests := []struct {
+		name string
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{name: "identical text", a: "Alien\nHorror, Sci-Fi", b: "Alien\nHorror, Sci-Fi", min: 1 - 1e-5, max: 1 + 1e-5},
+		{name: "case and punctuation are ignored", a: "Alien: Horror!", b: "alien horror", min: 1 - 1e-5, max: 1 + 1e-5},
+		{name: "shared words", a: "space horror on a ship", b: "horror on a haunted ship", min: 0.3, max: 0.9},
+		{name: "no shared words", a: "romantic comedy in paris", b: "zombie apocalypse survival", min: -0.2, max: 0.2},
+	}
+	for _, tt := range tests {
+		similarity := dot(embedder.embed(tt.a), embedder.embed(tt.b))
+		if similarity < tt.min || similarity > tt.max {
+			t.Errorf("%s: similarity of %q and %q = %v, want in [%v, %v]", tt.name, tt.a, tt.b, similarity, tt.min, tt.max)
+		}
+	}
+}
+
+func TestHashingEmbedderEmptyText(t *testing.T) {
+	for _, value := range NewHashingEmbedder(16).embed("  ,, ") {
+		if value != 0 {
+			t.Fatalf("vector of empty text has non-zero value %v", value)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"math"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// defines sentiment weights of words, positive words push review towards best ranking and negative words towards worst one
+var sentimentWords = map[string]float64{
+	"masterpiece": 2, "sublime": 2, "excellent": 2, "outstanding": 2, "brilliant": 2, "superb": 2,
+	"amazing": 2, "phenomenal": 2, "perfect": 2, "incredible": 2, "stunning": 2, "best": 2,
+	"loved": 1.5, "love": 1.5, "fantastic": 1.5, "wonderful": 1.5, "great": 1.5, "beautiful": 1.5,
+	"good": 1, "enjoyed": 1, "enjoyable": 1, "fun": 1, "liked": 1, "nice": 1, "solid": 1, "entertaining": 1,
+	"decent": 0.5, "fine": 0.3, "okay": 0, "ok": 0, "average": 0, "mediocre": -0.5,
+	"boring": -1, "bad": -1, "dull": -1, "weak": -1, "disappointing": -1, "slow": -0.5, "predictable": -0.5,
+	"poor": -1.5, "hated": -1.5, "hate": -1.5, "waste": -1.5, "annoying": -1,
+	"terrible": -2, "awful": -2, "horrible": -2, "worst": -2, "garbage": -2, "unwatchable": -2, "dreadful": -2,
+}
+
+// defines words that flip sentiment of following words
+var negationWords = map[string]bool{
+	"not": true, "no": true, "never": true, "hardly": true, "isn": true, "wasn": true, "didn": true, "don": true, "nothing": true,
+}
+
+// defines how many words after negation are flipped
+const negationWindow = 3
+
+// defines KeywordRankingClassifier that ranks review by counting sentiment words, it works offline and always gives the same result for the same review
+type KeywordRankingClassifier struct{}
+
+// creates function that builds KeywordRankingClassifier
+func NewKeywordRankingClassifier() *KeywordRankingClassifier {
+	return &KeywordRankingClassifier{}
+}
+
+// creates method that classifies review using sentiment words
+func (k *KeywordRankingClassifier) Classify(ctx context.Context, review string, rankings []models.Ranking) (models.Ranking, error) {
+	// defines total sentiment, number of sentiment words and position of last negation
+	total, matched, lastNegation := 0.0, 0, -negationWindow-1
+
+	for i, word := range utils.Tokenize(review) {
+		// remembers position of negation
+		if negationWords[word] {
+			lastNegation = i
+			continue
+		}
+		weight, ok := sentimentWords[word]
+		if !ok {
+			continue
+		}
+		// flips weight of word that follows negation
+		if i-lastNegation <= negationWindow {
+			weight = -weight * 0.75
+		}
+		total += weight
+		matched++
+	}
+
+	// defines rankings that can be assigned ordered from best to worst
+	ordered := orderedRankings(rankings)
+
+	// if review has no sentiment words or there are no rankings, review can not be ranked
+	if matched == 0 || len(ordered) == 0 {
+		return notRankedRanking(rankings), nil
+	}
+
+	// maps average sentiment from [-2, 2] range to position in ordered rankings
+	average := math.Max(-2, math.Min(2, total/float64(matched)))
+	index := int(math.Round((2 - average) / 4 * float64(len(ordered)-1)))
+
+	return ordered[index], nil
+}
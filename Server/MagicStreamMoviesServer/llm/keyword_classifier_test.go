@@ -0,0 +1,52 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"testing"
+)
+
+func TestKeywordRankingClassifier(t *testing.T) {
+	classifier := NewKeywordRankingClassifier()
+
+	tests := []struct {
+		name   string
+		review string
+		want   string
+	}{
+		{name: "strong praise", review: "A masterpiece, simply brilliant", want: "Excellent"},
+		{name: "words are compared without case", review: "BRILLIANT!!!", want: "Excellent"},
+		{name: "mild praise", review: "I enjoyed it, good fun", want: "Good"},
+		{name: "neutral", review: "It was okay", want: "Okay"},
+		{name: "mixed", review: "Great cast but boring and predictable", want: "Okay"},
+		{name: "strong criticism", review: "Terrible acting and an awful script", want: "Bad"},
+		{name: "negated criticism", review: "Not bad at all", want: "Good"},
+		{name: "negated strong criticism", review: "This was not terrible", want: "Excellent"},
+		{name: "negated praise", review: "It wasn't great", want: "Okay"},
+		{name: "every negation flips its own words", review: "Never boring, never dull", want: "Good"},
+		{name: "negation only reaches next words", review: "Not the film I expected, it was brilliant", want: "Excellent"},
+		{name: "no sentiment words", review: "A film about a bank heist in Paris", want: "Not_Ranked"},
+		{name: "empty review", review: "", want: "Not_Ranked"},
+	}
+	for _, tt := range tests {
+		got, err := classifier.Classify(context.Background(), tt.review, testRankings)
+		if err != nil {
+			t.Errorf("%s: Classify(%q) returned error: %v", tt.name, tt.review, err)
+			continue
+		}
+		if got.RankingName != tt.want {
+			t.Errorf("%s: Classify(%q) = %q, want %q", tt.name, tt.review, got.RankingName, tt.want)
+		}
+	}
+}
+
+func TestKeywordRankingClassifierWithoutRankings(t *testing.T) {
+	got, err := NewKeywordRankingClassifier().Classify(context.Background(), "brilliant", nil)
+	if err != nil {
+		t.Fatalf("Classify returned error: %v", err)
+	}
+	if got.RankingValue != NotRankedValue {
+		t.Errorf("Classify without rankings = %+v, want not ranked", got)
+	}
+}
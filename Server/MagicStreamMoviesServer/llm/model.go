@@ -0,0 +1,76 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// defines names of supported llm providers
+const (
+	ProviderOpenAI  = "openai"
+	ProviderOllama  = "ollama"
+	ProviderKeyword = "keyword"
+)
+
+// defines default local ollama settings
+const (
+	defaultOllamaServerURL = "http://localhost:11434"
+	defaultOllamaModel     = "llama3"
+)
+
+// creates function that gets configured llm provider name from LLM_PROVIDER env, uses openai when api key is set and keyword classifier otherwise
+func ProviderFromEnv() string {
+	// gets provider name from env
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("LLM_PROVIDER")))
+	if provider != "" {
+		return provider
+	}
+
+	// picks default provider
+	if os.Getenv("OPENAI_API_KEY") != "" {
+		return ProviderOpenAI
+	}
+	return ProviderKeyword
+}
+
+// creates function that builds language model of given provider using settings from env
+func NewModel(provider string) (llms.Model, error) {
+	switch provider {
+	case ProviderOpenAI:
+		// gets openai api key from env
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		// if openai api key is empty, returns error
+		if apiKey == "" {
+			return nil, errors.New("could not read OPENAI_API_KEY")
+		}
+		// defines openai options, model can be overridden with OPENAI_MODEL env
+		opts := []openai.Option{openai.WithToken(apiKey)}
+		if model := os.Getenv("OPENAI_MODEL"); model != "" {
+			opts = append(opts, openai.WithModel(model))
+		}
+		return openai.New(opts...)
+
+	case ProviderOllama:
+		// gets ollama server url and model from env
+		serverURL := os.Getenv("OLLAMA_SERVER_URL")
+		if serverURL == "" {
+			serverURL = defaultOllamaServerURL
+		}
+		model := os.Getenv("OLLAMA_MODEL")
+		if model == "" {
+			model = defaultOllamaModel
+		}
+		return ollama.New(ollama.WithServerURL(serverURL), ollama.WithModel(model))
+
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q", provider)
+	}
+}
@@ -0,0 +1,252 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// defines PromptParser interface that turns free text recommendation request into genre and ranking filters
+type PromptParser interface {
+	Parse(ctx context.Context, prompt string, genres []models.Genre, rankings []models.Ranking) (models.RecommendationQuery, error)
+}
+
+// creates function that builds prompt parser configured with LLM_PROVIDER env, llm parser falls back to rule based parser
+func NewPromptParserFromEnv() (PromptParser, error) {
+	// gets provider name from env
+	provider := ProviderFromEnv()
+	if provider == ProviderKeyword {
+		return NewRulePromptParser(), nil
+	}
+
+	// builds language model of provider
+	model, err := NewModel(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FallbackPromptParser{
+		Primary:  NewLLMPromptParser(model, os.Getenv("RECOMMENDATION_PROMPT_TEMPLATE")),
+		Fallback: NewRulePromptParser(),
+	}, nil
+}
+
+// defines LLMPromptParser that asks language model to pick genres and rankings that fit request
+type LLMPromptParser struct {
+	model          llms.Model
+	promptTemplate string
+}
+
+// creates function that builds LLMPromptParser, prompt template must contain {genres} and {rankings} placeholders and is followed by request
+func NewLLMPromptParser(model llms.Model, promptTemplate string) *LLMPromptParser {
+	if promptTemplate == "" {
+		promptTemplate = defaultRecommendationPromptTemplate
+	}
+	return &LLMPromptParser{model: model, promptTemplate: promptTemplate}
+}
+
+// defines default recommendation prompt template
+const defaultRecommendationPromptTemplate = "You turn movie requests into search filters. Allowed genres: {genres}. " +
+	"Allowed rankings from best to worst: {rankings}. Reply only with a JSON object such as " +
+	`{"genres": ["Comedy"], "rankings": ["Excellent", "Good"]}` + " using only names from the lists above. " +
+	"Use empty lists when request does not ask for genre or quality. Request: "
+
+// creates method that parses request using language model
+func (l *LLMPromptParser) Parse(ctx context.Context, prompt string, genres []models.Genre, rankings []models.Ranking) (models.RecommendationQuery, error) {
+	// defines names of genres and rankings ordered from best to worst
+	genreNames := make([]string, len(genres))
+	for i, genre := range genres {
+		genreNames[i] = genre.GenreName
+	}
+	rankingNames := rankingNames(orderedRankings(rankings))
+
+	// replaces placeholders with names
+	request := strings.NewReplacer("{genres}", strings.Join(genreNames, ","), "{rankings}", strings.Join(rankingNames, ",")).Replace(l.promptTemplate)
+
+	// defines response using llm based on request
+	response, err := llms.GenerateFromSinglePrompt(ctx, l.model, request+prompt)
+	if err != nil {
+		return models.RecommendationQuery{}, err
+	}
+
+	// cuts json object out of answer, models often wrap it in text or code block
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return models.RecommendationQuery{}, errors.New("llm answer does not contain json object")
+	}
+	var answer models.RecommendationQuery
+	if err := json.Unmarshal([]byte(response[start:end+1]), &answer); err != nil {
+		return models.RecommendationQuery{}, err
+	}
+
+	// keeps only names that exist, answer may contain typos or invented names
+	var query models.RecommendationQuery
+	for _, name := range answer.Genres {
+		if genre, ok := matchGenre(name, genres); ok {
+			query.Genres = appendUnique(query.Genres, genre.GenreName)
+		}
+	}
+	for _, name := range answer.Rankings {
+		if ranking, ok := MatchRanking(name, rankings); ok {
+			query.Rankings = appendUnique(query.Rankings, ranking.RankingName)
+		}
+	}
+	return query, nil
+}
+
+// defines words that point to genre, values are normalized genre names
+var genreSynonyms = map[string][]string{
+	"funny": {"comedy"}, "hilarious": {"comedy"}, "laugh": {"comedy"}, "light": {"comedy"}, "lighthearted": {"comedy"}, "comedies": {"comedy"},
+	"family": {"family"}, "kids": {"family", "animation"}, "children": {"family", "animation"},
+	"scary": {"horror"}, "spooky": {"horror"}, "creepy": {"horror"},
+	"romantic": {"romance"}, "date": {"romance"},
+	"explosions": {"action"}, "fights": {"action"},
+	"space": {"sci_fi", "science_fiction"}, "scifi": {"sci_fi", "science_fiction"}, "future": {"sci_fi", "science_fiction"},
+	"futuristic": {"sci_fi", "science_fiction"}, "aliens": {"sci_fi", "science_fiction"}, "robots": {"sci_fi", "science_fiction"},
+	"cartoon": {"animation"}, "animated": {"animation"},
+	"suspense": {"thriller"}, "tense": {"thriller"}, "thrilling": {"thriller"},
+	"magic": {"fantasy"}, "dragons": {"fantasy"}, "wizards": {"fantasy"},
+	"detective": {"mystery", "crime"}, "whodunit": {"mystery"},
+	"heist": {"crime"}, "gangster": {"crime"}, "mafia": {"crime"},
+	"emotional": {"drama"}, "moving": {"drama"},
+	"cowboy": {"western"}, "cowboys": {"western"},
+	"quest": {"adventure"}, "journey": {"adventure"},
+}
+
+// defines words that ask for well ranked movies, values tell how many best rankings fit
+var qualityWords = map[string]int{
+	"best": 1, "top": 1, "masterpiece": 1, "acclaimed": 1, "classic": 1, "greatest": 1,
+	"good": 2, "great": 2, "solid": 2, "decent": 2, "highly": 2, "quality": 2,
+}
+
+// defines RulePromptParser that finds genre and ranking names, their synonyms and quality words in request, it works offline
+type RulePromptParser struct{}
+
+// creates function that builds RulePromptParser
+func NewRulePromptParser() *RulePromptParser {
+	return &RulePromptParser{}
+}
+
+// creates method that parses request using rules
+func (r *RulePromptParser) Parse(ctx context.Context, prompt string, genres []models.Genre, rankings []models.Ranking) (models.RecommendationQuery, error) {
+	words := utils.Tokenize(prompt)
+	text := " " + strings.Join(words, " ") + " "
+
+	// defines genres by normalized name
+	genresByName := map[string]models.Genre{}
+	for _, genre := range genres {
+		genresByName[normalizeRankingName(genre.GenreName)] = genre
+	}
+
+	var query models.RecommendationQuery
+
+	// finds genre names in request, names may have several words, e.g. "Sci-Fi"
+	for _, genre := range genres {
+		name := strings.Join(utils.Tokenize(genre.GenreName), " ")
+		if name != "" && strings.Contains(text, " "+name+" ") {
+			query.Genres = appendUnique(query.Genres, genre.GenreName)
+		}
+	}
+
+	// finds synonyms of genres and quality words
+	bestRankings := 0
+	for _, word := range words {
+		for _, name := range genreSynonyms[word] {
+			if genre, ok := genresByName[name]; ok {
+				query.Genres = appendUnique(query.Genres, genre.GenreName)
+			}
+		}
+		if count, ok := qualityWords[word]; ok && (bestRankings == 0 || count < bestRankings) {
+			bestRankings = count
+		}
+	}
+
+	// finds ranking names in request, they are more precise than quality words
+	for _, word := range words {
+		for _, ranking := range rankings {
+			if ranking.RankingValue != NotRankedValue && normalizeRankingName(ranking.RankingName) == word {
+				query.Rankings = appendUnique(query.Rankings, ranking.RankingName)
+			}
+		}
+	}
+	if len(query.Rankings) == 0 && bestRankings > 0 {
+		ordered := orderedRankings(rankings)
+		for _, ranking := range ordered[:min(bestRankings, len(ordered))] {
+			query.Rankings = append(query.Rankings, ranking.RankingName)
+		}
+	}
+
+	return query, nil
+}
+
+// defines FallbackPromptParser that uses fallback parser when primary parser fails or finds nothing
+type FallbackPromptParser struct {
+	Primary  PromptParser
+	Fallback PromptParser
+}
+
+// creates method that parses request using primary parser and falls back to fallback parser
+func (f *FallbackPromptParser) Parse(ctx context.Context, prompt string, genres []models.Genre, rankings []models.Ranking) (models.RecommendationQuery, error) {
+	query, err := f.Primary.Parse(ctx, prompt, genres, rankings)
+	if err == nil && !query.IsEmpty() {
+		return query, nil
+	}
+
+	if err != nil {
+		log.Println("Warning: prompt parser failed, using fallback parser:", err)
+	}
+	return f.Fallback.Parse(ctx, prompt, genres, rankings)
+}
+
+// creates function that matches name with genres, ignores case, punctuation and small typos
+func matchGenre(name string, genres []models.Genre) (models.Genre, bool) {
+	normalized := normalizeRankingName(name)
+	if normalized == "" {
+		return models.Genre{}, false
+	}
+
+	var best models.Genre
+	bestDistance, ties := maxRankingTypos+1, 0
+	for _, genre := range genres {
+		distance := utils.LevenshteinDistance(normalized, normalizeRankingName(genre.GenreName))
+		switch {
+		case distance < bestDistance:
+			best, bestDistance, ties = genre, distance, 1
+		case distance == bestDistance:
+			ties++
+		}
+	}
+	return best, bestDistance <= maxRankingTypos && ties == 1
+}
+
+// creates function that gets rankings that can be assigned ordered from best to worst
+func orderedRankings(rankings []models.Ranking) []models.Ranking {
+	var ordered []models.Ranking
+	for _, ranking := range rankings {
+		if ranking.RankingValue != NotRankedValue {
+			ordered = append(ordered, ranking)
+		}
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].RankingValue < ordered[j].RankingValue })
+	return ordered
+}
+
+// creates function that appends value to slice if slice does not contain it yet
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,75 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// defines genres and rankings used by parser tests
+var (
+	testGenres = []models.Genre{
+		{GenreID: 1, GenreName: "Comedy"},
+		{GenreID: 2, GenreName: "Horror"},
+		{GenreID: 3, GenreName: "Sci-Fi"},
+	}
+	testRankings = []models.Ranking{
+		{RankingValue: 1, RankingName: "Excellent"},
+		{RankingValue: 2, RankingName: "Good"},
+		{RankingValue: 3, RankingName: "Okay"},
+		{RankingValue: 4, RankingName: "Bad"},
+		{RankingValue: NotRankedValue, RankingName: "Not_Ranked"},
+	}
+)
+
+func TestRulePromptParser(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   models.RecommendationQuery
+	}{
+		// prompts without hints give empty query, so that usual recommendations are returned
+		{prompt: "", want: models.RecommendationQuery{}},
+		{prompt: "something to watch tonight", want: models.RecommendationQuery{}},
+		{prompt: "a sci-fi movie", want: models.RecommendationQuery{Genres: []string{"Sci-Fi"}}},
+		{prompt: "best comedy", want: models.RecommendationQuery{Genres: []string{"Comedy"}, Rankings: []string{"Excellent"}}},
+		{prompt: "a good horror film", want: models.RecommendationQuery{Genres: []string{"Horror"}, Rankings: []string{"Good"}}},
+	}
+
+	parser := NewRulePromptParser()
+	for _, tt := range tests {
+		got, err := parser.Parse(context.Background(), tt.prompt, testGenres, testRankings)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", tt.prompt, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.prompt, got, tt.want)
+		}
+		if got.IsEmpty() != (len(tt.want.Genres) == 0 && len(tt.want.Rankings) == 0) {
+			t.Errorf("Parse(%q).IsEmpty() = %v", tt.prompt, got.IsEmpty())
+		}
+	}
+}
+
+// defines failingPromptParser that always fails
+type failingPromptParser struct{}
+
+func (failingPromptParser) Parse(context.Context, string, []models.Genre, []models.Ranking) (models.RecommendationQuery, error) {
+	return models.RecommendationQuery{}, errors.New("provider unavailable")
+}
+
+func TestFallbackPromptParser(t *testing.T) {
+	parser := &FallbackPromptParser{Primary: failingPromptParser{}, Fallback: NewRulePromptParser()}
+
+	got, err := parser.Parse(context.Background(), "a horror movie", testGenres, testRankings)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if want := []string{"Horror"}; !reflect.DeepEqual(got.Genres, want) {
+		t.Errorf("Genres = %v, want %v", got.Genres, want)
+	}
+}
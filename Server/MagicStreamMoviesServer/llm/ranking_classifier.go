@@ -0,0 +1,177 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// defines value of ranking that is used for reviews which can not be ranked
+const NotRankedValue = 999
+
+// defines RankingClassifier interface that picks one of rankings according to review sentiment
+type RankingClassifier interface {
+	Classify(ctx context.Context, review string, rankings []models.Ranking) (models.Ranking, error)
+}
+
+// creates function that builds ranking classifier configured with LLM_PROVIDER env, errors of llm classifiers are returned to caller
+// so that review queue can retry them, queue falls back to keyword classifier only after the last retry
+func NewRankingClassifierFromEnv() (RankingClassifier, error) {
+	// gets provider name from env
+	provider := ProviderFromEnv()
+	if provider == ProviderKeyword {
+		return NewKeywordRankingClassifier(), nil
+	}
+
+	// builds language model of provider
+	model, err := NewModel(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLLMRankingClassifier(model, os.Getenv("BASE_PROMPT_TEMPLATE")), nil
+}
+
+// defines LLMRankingClassifier that asks language model to pick ranking name
+type LLMRankingClassifier struct {
+	model          llms.Model
+	promptTemplate string
+}
+
+// creates function that builds LLMRankingClassifier, prompt template must contain {rankings} placeholder and is followed by review
+func NewLLMRankingClassifier(model llms.Model, promptTemplate string) *LLMRankingClassifier {
+	return &LLMRankingClassifier{model: model, promptTemplate: promptTemplate}
+}
+
+// creates method that classifies review using language model, retries with stricter prompt when answer does not match any ranking
+func (l *LLMRankingClassifier) Classify(ctx context.Context, review string, rankings []models.Ranking) (models.Ranking, error) {
+	// defines comma delimited ranking names
+	names := strings.Join(rankingNames(rankings), ",")
+
+	// tries base prompt first and stricter prompt after it
+	for _, template := range []string{l.promptTemplate, strictPromptTemplate()} {
+		// replaces {rankings} with ranking names
+		prompt := strings.Replace(template, "{rankings}", names, 1)
+
+		// defines response using llm based on review and prompt
+		response, err := llms.GenerateFromSinglePrompt(ctx, l.model, prompt+review)
+		if err != nil {
+			return models.Ranking{}, err
+		}
+
+		// matches response with rankings
+		if ranking, ok := MatchRanking(response, rankings); ok {
+			return ranking, nil
+		}
+		log.Printf("Warning: llm answer %q does not match any ranking", response)
+	}
+
+	// review could not be classified
+	return notRankedRanking(rankings), nil
+}
+
+// creates function that gets strict prompt template used to retry classification, can be overridden with STRICT_PROMPT_TEMPLATE env
+func strictPromptTemplate() string {
+	if template := os.Getenv("STRICT_PROMPT_TEMPLATE"); template != "" {
+		return template
+	}
+	return defaultStrictPromptTemplate
+}
+
+// defines default strict prompt template
+const defaultStrictPromptTemplate = "You are a classifier. Reply with exactly one word copied from this list: {rankings}. " +
+	"Do not add punctuation, explanations or any other text. Review: "
+
+// creates function that matches llm answer with ranking names, ignores case, punctuation and small typos
+func MatchRanking(response string, rankings []models.Ranking) (models.Ranking, bool) {
+	// defines rankings that can be assigned by normalized name
+	candidates := map[string]models.Ranking{}
+	for _, ranking := range rankings {
+		if ranking.RankingValue != NotRankedValue {
+			candidates[normalizeRankingName(ranking.RankingName)] = ranking
+		}
+	}
+
+	// splits answer into words
+	words := utils.Tokenize(response)
+	if len(words) == 0 {
+		return models.Ranking{}, false
+	}
+
+	// answer is ranking name itself, e.g. "Good." or "excellent"
+	if ranking, ok := candidates[strings.Join(words, "_")]; ok {
+		return ranking, true
+	}
+
+	// answer mentions exactly one ranking name, e.g. "The answer is Good"
+	var mentioned []models.Ranking
+	seen := map[string]bool{}
+	for _, word := range words {
+		if ranking, ok := candidates[word]; ok && !seen[word] {
+			seen[word] = true
+			mentioned = append(mentioned, ranking)
+		}
+	}
+	if len(mentioned) == 1 {
+		return mentioned[0], true
+	}
+	if len(mentioned) > 1 {
+		return models.Ranking{}, false
+	}
+
+	// single word answer is close to exactly one ranking name, e.g. "Exellent"
+	if len(words) == 1 {
+		var best models.Ranking
+		bestDistance, ties := maxRankingTypos+1, 0
+		for name, ranking := range candidates {
+			distance := utils.LevenshteinDistance(words[0], name)
+			switch {
+			case distance < bestDistance:
+				best, bestDistance, ties = ranking, distance, 1
+			case distance == bestDistance:
+				ties++
+			}
+		}
+		if bestDistance <= maxRankingTypos && ties == 1 {
+			return best, true
+		}
+	}
+
+	return models.Ranking{}, false
+}
+
+// defines how many typos are tolerated in ranking name
+const maxRankingTypos = 2
+
+// creates function that normalizes ranking name, e.g. "Not_Ranked" becomes "not_ranked"
+func normalizeRankingName(name string) string {
+	return strings.Join(utils.Tokenize(name), "_")
+}
+
+// creates function that returns not ranked ranking from rankings, builds it when rankings collection does not contain it
+func notRankedRanking(rankings []models.Ranking) models.Ranking {
+	for _, ranking := range rankings {
+		if ranking.RankingValue == NotRankedValue {
+			return ranking
+		}
+	}
+	return models.Ranking{RankingValue: NotRankedValue, RankingName: "Not_Ranked"}
+}
+
+// creates function that gets names of rankings that can be assigned to review
+func rankingNames(rankings []models.Ranking) []string {
+	var names []string
+	for _, ranking := range rankings {
+		if ranking.RankingValue != NotRankedValue {
+			names = append(names, ranking.RankingName)
+		}
+	}
+	return names
+}
@@ -0,0 +1,38 @@
+// marks file as part of llm package
+package llm
+
+// imports packages
+import "testing"
+
+func TestMatchRanking(t *testing.T) {
+	tests := []struct {
+		response string
+		want     string
+		wantOk   bool
+	}{
+		{response: "Good", want: "Good", wantOk: true},
+		{response: "  excellent. ", want: "Excellent", wantOk: true},
+		{response: "The answer is Bad", want: "Bad", wantOk: true},
+		{response: "Exellent", want: "Excellent", wantOk: true},
+		{response: "Okey", want: "Okay", wantOk: true},
+		// not ranked can not be picked by answer
+		{response: "Not_Ranked", wantOk: false},
+		// answers that mention several rankings or none are ambiguous
+		{response: "Good or Bad", wantOk: false},
+		{response: "I can not tell", wantOk: false},
+		{response: "", wantOk: false},
+		// word far from every ranking name
+		{response: "terrible", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := MatchRanking(tt.response, testRankings)
+		if ok != tt.wantOk {
+			t.Errorf("MatchRanking(%q) ok = %v, want %v", tt.response, ok, tt.wantOk)
+			continue
+		}
+		if ok && got.RankingName != tt.want {
+			t.Errorf("MatchRanking(%q) = %q, want %q", tt.response, got.RankingName, tt.want)
+		}
+	}
+}
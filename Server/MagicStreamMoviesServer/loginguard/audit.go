@@ -0,0 +1,39 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines AuditLog interface that records security related events
+type AuditLog interface {
+	Record(ctx context.Context, event models.AuditEvent)
+}
+
+// defines MongoAuditLog that stores events in audit_events collection and writes them to server log
+type MongoAuditLog struct {
+	collection *mongo.Collection
+}
+
+// creates function that builds MongoAuditLog
+func NewMongoAuditLog(client *mongo.Client) *MongoAuditLog {
+	return &MongoAuditLog{collection: database.OpenCollection("audit_events", client)}
+}
+
+// creates method that records event, failure to store event is only logged so that it never blocks login
+func (a *MongoAuditLog) Record(ctx context.Context, event models.AuditEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	log.Printf("audit: %s email=%q ip=%q user_id=%q actor=%q", event.Type, event.Email, event.IP, event.UserID, event.Actor)
+	if _, err := a.collection.InsertOne(ctx, event); err != nil {
+		log.Println("Warning: unable to store audit event:", err)
+	}
+}
@@ -0,0 +1,288 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines names of attempt stores
+const (
+	StoreMemory = "memory"
+	StoreMongo  = "mongo"
+)
+
+// defines default login guard settings
+const (
+	defaultMaxAccountFailures = 5
+	defaultMaxIPFailures      = 50
+	defaultBaseDelay          = time.Second
+	defaultMaxDelay           = 5 * time.Minute
+	defaultLockoutDuration    = 15 * time.Minute
+	defaultAttemptWindow      = 15 * time.Minute
+)
+
+// defines prefixes of attempt store keys
+const (
+	accountKeyPrefix = "email:"
+	ipKeyPrefix      = "ip:"
+)
+
+// defines Policy struct that holds limits of failed logins
+type Policy struct {
+	// MaxAccountFailures is number of failures after which account is locked
+	MaxAccountFailures int
+	// MaxIPFailures is number of failures after which client ip is locked, it is higher because many users can share one ip
+	MaxIPFailures int
+	// BaseDelay is wait after first failure of account, it doubles with every next failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutDuration is how long account or ip stays locked
+	LockoutDuration time.Duration
+	// Window is how long failures are remembered after the last one
+	Window time.Duration
+}
+
+// defines Decision struct that tells if login attempt may go on
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	// Reason is "backoff", "account_locked" or "ip_locked" when attempt is not allowed
+	Reason string
+}
+
+// defines Guard that protects login against brute force by counting failed attempts per account and per client ip
+type Guard struct {
+	Store  AttemptStore
+	Audit  AuditLog
+	Policy Policy
+}
+
+// creates function that builds guard with store and policy from env, LOGIN_ATTEMPT_STORE picks memory or mongo (default) store
+func NewGuardFromEnv(client *mongo.Client) (*Guard, error) {
+	var store AttemptStore
+	switch name := strings.ToLower(strings.TrimSpace(os.Getenv("LOGIN_ATTEMPT_STORE"))); name {
+	case "", StoreMongo:
+		store = NewMongoStore(client)
+	case StoreMemory:
+		store = NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown LOGIN_ATTEMPT_STORE %q", name)
+	}
+
+	policy := Policy{
+		MaxAccountFailures: envInt("LOGIN_MAX_ACCOUNT_FAILURES", defaultMaxAccountFailures),
+		MaxIPFailures:      envInt("LOGIN_MAX_IP_FAILURES", defaultMaxIPFailures),
+		BaseDelay:          envDuration("LOGIN_BACKOFF_BASE_SECONDS", time.Second, defaultBaseDelay),
+		MaxDelay:           envDuration("LOGIN_BACKOFF_MAX_SECONDS", time.Second, defaultMaxDelay),
+		LockoutDuration:    envDuration("LOGIN_LOCKOUT_MINUTES", time.Minute, defaultLockoutDuration),
+		Window:             envDuration("LOGIN_ATTEMPT_WINDOW_MINUTES", time.Minute, defaultAttemptWindow),
+	}
+	return &Guard{Store: store, Audit: NewMongoAuditLog(client), Policy: policy}, nil
+}
+
+// creates method that checks if account and client ip may try to log in now
+func (g *Guard) Check(ctx context.Context, email, ip string) (Decision, error) {
+	now := time.Now()
+
+	// checks if client ip is locked
+	ipRecord, err := g.Store.Get(ctx, ipKey(ip))
+	if err != nil {
+		return Decision{}, err
+	}
+	if now.Before(ipRecord.LockedUntil) {
+		return Decision{RetryAfter: ipRecord.LockedUntil.Sub(now), Reason: models.AuditIPLocked}, nil
+	}
+
+	// checks if account is locked
+	accountRecord, err := g.Store.Get(ctx, accountKey(email))
+	if err != nil {
+		return Decision{}, err
+	}
+	if now.Before(accountRecord.LockedUntil) {
+		return Decision{RetryAfter: accountRecord.LockedUntil.Sub(now), Reason: models.AuditAccountLocked}, nil
+	}
+
+	// checks if account still has to wait after its last failure
+	if accountRecord.Failures > 0 {
+		if next := accountRecord.LastFailure.Add(g.backoff(accountRecord.Failures)); now.Before(next) {
+			return Decision{RetryAfter: next.Sub(now), Reason: "backoff"}, nil
+		}
+	}
+	return Decision{Allowed: true}, nil
+}
+
+// creates method that reserves login attempt before password is compared, attempt is counted as failure in one atomic store update
+// so that parallel requests can not all pass the same check, Success takes it back when password is right, attempts refused here
+// stay counted and bring lockout closer
+func (g *Guard) Reserve(ctx context.Context, email, ip string) (Decision, error) {
+	// locked account or ip is refused without counting attempt, so that lock is not extended
+	decision, err := g.Check(ctx, email, ip)
+	if err != nil || (!decision.Allowed && decision.Reason != "backoff") {
+		return decision, err
+	}
+
+	now := time.Now()
+	accountRecord, err := g.Store.RecordFailure(ctx, accountKey(email), now, g.Policy.Window)
+	if err != nil {
+		return Decision{}, err
+	}
+	ipRecord, err := g.Store.RecordFailure(ctx, ipKey(ip), now, g.Policy.Window)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	// locks account or ip whose attempts went over limit while other attempts were in progress
+	if accountRecord.Failures > g.Policy.MaxAccountFailures || ipRecord.Failures > g.Policy.MaxIPFailures {
+		if err := g.lockReached(ctx, now, email, ip, "", accountRecord, ipRecord); err != nil {
+			return Decision{}, err
+		}
+		return g.Check(ctx, email, ip)
+	}
+
+	// checks if account still had to wait after failure before this attempt
+	if previous := accountRecord.Failures - 1; previous > 0 {
+		if next := accountRecord.PreviousFailure.Add(g.backoff(previous)); now.Before(next) {
+			return Decision{RetryAfter: next.Sub(now), Reason: "backoff"}, nil
+		}
+	}
+	return Decision{Allowed: true}, nil
+}
+
+// creates method that records failed login of reserved attempt and locks account and client ip once they reach their limits
+func (g *Guard) Failure(ctx context.Context, email, ip, userId string) error {
+	now := time.Now()
+
+	accountRecord, err := g.Store.Get(ctx, accountKey(email))
+	if err != nil {
+		return err
+	}
+	ipRecord, err := g.Store.Get(ctx, ipKey(ip))
+	if err != nil {
+		return err
+	}
+	g.record(ctx, models.AuditEvent{Type: models.AuditLoginFailed, Email: email, IP: ip, UserID: userId,
+		Details: map[string]any{"account_failures": accountRecord.Failures, "ip_failures": ipRecord.Failures}})
+	return g.lockReached(ctx, now, email, ip, userId, accountRecord, ipRecord)
+}
+
+// creates method that forgets failures of account after successful login and takes back attempt reserved on client ip,
+// earlier failures of client ip are kept so that attacker can not reset them by logging into own account
+func (g *Guard) Success(ctx context.Context, email, ip, userId string) error {
+	if err := g.Store.Release(ctx, ipKey(ip)); err != nil {
+		return err
+	}
+	record, err := g.Store.Get(ctx, accountKey(email))
+	if err != nil {
+		return err
+	}
+	if record.Failures == 0 {
+		return nil
+	}
+	if err := g.Store.Reset(ctx, accountKey(email)); err != nil {
+		return err
+	}
+	// reserved attempt of this login is not one of failures
+	if record.Failures > 1 {
+		g.record(ctx, models.AuditEvent{Type: models.AuditLoginSucceeded, Email: email, IP: ip, UserID: userId,
+			Details: map[string]any{"previous_failures": record.Failures - 1}})
+	}
+	return nil
+}
+
+// creates method that takes back reserved attempt when login could not be checked, for example because database failed
+func (g *Guard) Cancel(ctx context.Context, email, ip string) error {
+	if err := g.Store.Release(ctx, accountKey(email)); err != nil {
+		return err
+	}
+	return g.Store.Release(ctx, ipKey(ip))
+}
+
+// creates method that lets admin unlock account and/or client ip, their failures are forgotten too
+func (g *Guard) Unlock(ctx context.Context, email, ip, actor string) error {
+	if email != "" {
+		if err := g.Store.Reset(ctx, accountKey(email)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := g.Store.Reset(ctx, ipKey(ip)); err != nil {
+			return err
+		}
+	}
+	g.record(ctx, models.AuditEvent{Type: models.AuditAccountUnlocked, Email: email, IP: ip, Actor: actor})
+	return nil
+}
+
+// creates method that locks account and client ip whose failures reached their limits, failures of locked key do not extend lock
+func (g *Guard) lockReached(ctx context.Context, now time.Time, email, ip, userId string, accountRecord, ipRecord AttemptRecord) error {
+	until := now.Add(g.Policy.LockoutDuration)
+	if accountRecord.Failures >= g.Policy.MaxAccountFailures && !now.Before(accountRecord.LockedUntil) {
+		if err := g.Store.Lock(ctx, accountKey(email), until); err != nil {
+			return err
+		}
+		g.record(ctx, models.AuditEvent{Type: models.AuditAccountLocked, Email: email, IP: ip, UserID: userId,
+			Details: map[string]any{"failures": accountRecord.Failures, "locked_until": until}})
+	}
+	if ipRecord.Failures >= g.Policy.MaxIPFailures && !now.Before(ipRecord.LockedUntil) {
+		if err := g.Store.Lock(ctx, ipKey(ip), until); err != nil {
+			return err
+		}
+		g.record(ctx, models.AuditEvent{Type: models.AuditIPLocked, IP: ip,
+			Details: map[string]any{"failures": ipRecord.Failures, "locked_until": until}})
+	}
+	return nil
+}
+
+// creates method that gets wait after given number of failures, it doubles with every failure up to MaxDelay
+func (g *Guard) backoff(failures int) time.Duration {
+	delay := g.Policy.BaseDelay
+	for i := 1; i < failures && delay < g.Policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, g.Policy.MaxDelay)
+}
+
+// creates method that records audit event when guard has audit log
+func (g *Guard) record(ctx context.Context, event models.AuditEvent) {
+	if g.Audit == nil {
+		log.Printf("audit: %s email=%q ip=%q", event.Type, event.Email, event.IP)
+		return
+	}
+	g.Audit.Record(ctx, event)
+}
+
+// creates function that builds store key of account, emails are compared without case
+func accountKey(email string) string {
+	return accountKeyPrefix + strings.ToLower(strings.TrimSpace(email))
+}
+
+// creates function that builds store key of client ip
+func ipKey(ip string) string {
+	return ipKeyPrefix + ip
+}
+
+// creates function that reads positive integer from env
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// creates function that reads positive number of units from env
+func envDuration(name string, unit, fallback time.Duration) time.Duration {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return time.Duration(value) * unit
+	}
+	return fallback
+}
@@ -0,0 +1,223 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// defines auditRecorder that keeps types of recorded events in memory
+type auditRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+// creates method that records type of event
+func (a *auditRecorder) Record(ctx context.Context, event models.AuditEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event.Type)
+}
+
+// creates method that counts recorded events of given type
+func (a *auditRecorder) count(eventType string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for _, recorded := range a.events {
+		if recorded == eventType {
+			n++
+		}
+	}
+	return n
+}
+
+// creates function that builds guard with memory store, audit recorder and small limits
+func newTestGuard() (*Guard, *auditRecorder) {
+	audit := &auditRecorder{}
+	return &Guard{
+		Store: NewMemoryStore(),
+		Audit: audit,
+		Policy: Policy{
+			MaxAccountFailures: 3,
+			MaxIPFailures:      5,
+			BaseDelay:          time.Second,
+			MaxDelay:           4 * time.Second,
+			LockoutDuration:    time.Minute,
+			Window:             15 * time.Minute,
+		},
+	}, audit
+}
+
+// creates function that reserves attempt and records it as failed, like login with wrong password does
+func failLogin(t *testing.T, guard *Guard, email, ip string) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := guard.Reserve(ctx, email, ip); err != nil {
+		t.Fatalf("Reserve returned error: %v", err)
+	}
+	if err := guard.Failure(ctx, email, ip, ""); err != nil {
+		t.Fatalf("Failure returned error: %v", err)
+	}
+}
+
+// creates function that moves last failures of account back in time, so that test does not sleep through backoff
+func ageFailures(guard *Guard, email string, by time.Duration) {
+	store := guard.Store.(*MemoryStore)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	record := store.records[accountKey(email)]
+	record.LastFailure = record.LastFailure.Add(-by)
+	store.records[accountKey(email)] = record
+}
+
+func TestGuardBackoff(t *testing.T) {
+	guard, _ := newTestGuard()
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: time.Second},
+		{failures: 2, want: 2 * time.Second},
+		{failures: 3, want: 4 * time.Second},
+		{failures: 10, want: 4 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := guard.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestGuardReserve(t *testing.T) {
+	ctx := context.Background()
+	email, ip := "user@example.com", "203.0.113.7"
+
+	tests := []struct {
+		name        string
+		prepare     func(t *testing.T, guard *Guard)
+		wantAllowed bool
+		wantReason  string
+	}{
+		{name: "first attempt", prepare: func(t *testing.T, guard *Guard) {}, wantAllowed: true},
+		{name: "attempt right after failure waits", prepare: func(t *testing.T, guard *Guard) {
+			failLogin(t, guard, email, ip)
+		}, wantReason: "backoff"},
+		{name: "attempt after backoff", prepare: func(t *testing.T, guard *Guard) {
+			failLogin(t, guard, email, ip)
+			ageFailures(guard, email, time.Second)
+		}, wantAllowed: true},
+		{name: "email is compared without case", prepare: func(t *testing.T, guard *Guard) {
+			failLogin(t, guard, "USER@example.com", ip)
+		}, wantReason: "backoff"},
+		{name: "account locked after limit", prepare: func(t *testing.T, guard *Guard) {
+			for i := 0; i < guard.Policy.MaxAccountFailures; i++ {
+				failLogin(t, guard, email, ip)
+				ageFailures(guard, email, time.Minute)
+			}
+		}, wantReason: models.AuditAccountLocked},
+		{name: "ip locked after limit over many accounts", prepare: func(t *testing.T, guard *Guard) {
+			for _, other := range []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com", "e@example.com"} {
+				failLogin(t, guard, other, ip)
+			}
+		}, wantReason: models.AuditIPLocked},
+		{name: "admin unlock", prepare: func(t *testing.T, guard *Guard) {
+			for i := 0; i < guard.Policy.MaxAccountFailures; i++ {
+				failLogin(t, guard, email, ip)
+				ageFailures(guard, email, time.Minute)
+			}
+			if err := guard.Unlock(ctx, email, "", "admin"); err != nil {
+				t.Fatalf("Unlock returned error: %v", err)
+			}
+		}, wantAllowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, _ := newTestGuard()
+			tt.prepare(t, guard)
+			decision, err := guard.Reserve(ctx, email, ip)
+			if err != nil {
+				t.Fatalf("Reserve returned error: %v", err)
+			}
+			if decision.Allowed != tt.wantAllowed || decision.Reason != tt.wantReason {
+				t.Errorf("Reserve = %+v, want allowed %v reason %q", decision, tt.wantAllowed, tt.wantReason)
+			}
+			if !decision.Allowed && decision.RetryAfter <= 0 {
+				t.Errorf("RetryAfter = %v, want positive wait", decision.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestGuardSuccessTakesBackReservedAttempt(t *testing.T) {
+	ctx := context.Background()
+	guard, audit := newTestGuard()
+	email, ip := "user@example.com", "203.0.113.7"
+
+	failLogin(t, guard, email, ip)
+	ageFailures(guard, email, time.Minute)
+	if decision, _ := guard.Reserve(ctx, email, ip); !decision.Allowed {
+		t.Fatalf("Reserve = %+v, want allowed", decision)
+	}
+	if err := guard.Success(ctx, email, ip, "user-1"); err != nil {
+		t.Fatalf("Success returned error: %v", err)
+	}
+
+	// account failures are forgotten, client ip keeps its earlier failure but not reserved attempt
+	if record, _ := guard.Store.Get(ctx, accountKey(email)); record.Failures != 0 {
+		t.Errorf("account failures = %d, want 0", record.Failures)
+	}
+	if record, _ := guard.Store.Get(ctx, ipKey(ip)); record.Failures != 1 {
+		t.Errorf("ip failures = %d, want 1", record.Failures)
+	}
+	if got := audit.count(models.AuditLoginSucceeded); got != 1 {
+		t.Errorf("login_succeeded events = %d, want 1", got)
+	}
+}
+
+func TestGuardReserveAllowsOneOfParallelAttempts(t *testing.T) {
+	ctx := context.Background()
+	guard, audit := newTestGuard()
+	email, ip := "user@example.com", "203.0.113.7"
+
+	// parallel attempts are all reserved before any password is compared
+	const attempts = 20
+	decisions := make([]Decision, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			decision, err := guard.Reserve(ctx, email, ip)
+			if err != nil {
+				t.Errorf("Reserve returned error: %v", err)
+			}
+			decisions[i] = decision
+		}(i)
+	}
+	wg.Wait()
+
+	allowed := 0
+	for _, decision := range decisions {
+		if decision.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 1 {
+		t.Errorf("%d of %d parallel attempts were allowed, want 1", allowed, attempts)
+	}
+
+	// attempts over limit lock account and ip
+	if decision, _ := guard.Check(ctx, email, ip); decision.Allowed || decision.Reason == "backoff" {
+		t.Errorf("Check after parallel attempts = %+v, want locked", decision)
+	}
+	if audit.count(models.AuditAccountLocked) == 0 {
+		t.Error("account lock was not recorded")
+	}
+}
@@ -0,0 +1,81 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines MongoStore that keeps counters in login_attempts collection, so that they are shared by all server instances and survive restart
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// creates function that builds MongoStore
+func NewMongoStore(client *mongo.Client) *MongoStore {
+	return &MongoStore{collection: database.OpenCollection("login_attempts", client)}
+}
+
+// creates method that gets record of key
+func (m *MongoStore) Get(ctx context.Context, key string) (AttemptRecord, error) {
+	var record AttemptRecord
+	err := m.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&record)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return AttemptRecord{Key: key}, nil
+	}
+	return record, err
+}
+
+// creates method that adds failed attempt to key, counter is reset and increased in one atomic pipeline update
+func (m *MongoStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (AttemptRecord, error) {
+	// defines condition that is true when earlier failures are recent enough to count
+	recent := bson.M{"$gte": bson.A{bson.M{"$ifNull": bson.A{"$last_failure", time.Time{}}}, now.Add(-window)}}
+	lockedUntil := bson.M{"$ifNull": bson.A{"$locked_until", time.Time{}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"failures":     bson.M{"$cond": bson.A{recent, bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$failures", 0}}, 1}}, 1}},
+			"last_failure": now,
+			// stage expressions see document before update, so this keeps time of failure before this one
+			"previous_failure": bson.M{"$cond": bson.A{recent, bson.M{"$ifNull": bson.A{"$last_failure", time.Time{}}}, time.Time{}}},
+			"locked_until":     lockedUntil,
+			"expires_at":       bson.M{"$max": bson.A{now.Add(window), lockedUntil}},
+		}}},
+	}
+
+	var record AttemptRecord
+	err := m.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&record)
+	return record, err
+}
+
+// creates method that takes back one failure of key, counter never goes below zero
+func (m *MongoStore) Release(ctx context.Context, key string) error {
+	_, err := m.collection.UpdateOne(ctx, bson.M{"_id": key, "failures": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"failures": -1}})
+	return err
+}
+
+// creates method that blocks key until given time
+func (m *MongoStore) Lock(ctx context.Context, key string, until time.Time) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"locked_until": until,
+			"expires_at":   bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$expires_at", until}}, until}},
+		}}},
+	}
+	_, err := m.collection.UpdateOne(ctx, bson.M{"_id": key}, pipeline, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+// creates method that forgets key
+func (m *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := m.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
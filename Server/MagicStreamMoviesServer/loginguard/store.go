@@ -0,0 +1,126 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryStorePruneSize is number of records after which MemoryStore removes expired ones
+const memoryStorePruneSize = 10000
+
+// defines AttemptRecord struct that holds failed login attempts of one account or client ip
+type AttemptRecord struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LastFailure time.Time `bson:"last_failure"`
+	// PreviousFailure is time of failure before the last one, it is zero when there was none in window
+	PreviousFailure time.Time `bson:"previous_failure"`
+	LockedUntil     time.Time `bson:"locked_until"`
+	// ExpiresAt is when record is forgotten, it is used by ttl index of mongo store
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// defines AttemptStore interface that keeps counters of failed login attempts
+type AttemptStore interface {
+	// Get returns record of key, record with zero failures is returned when key is unknown or expired
+	Get(ctx context.Context, key string) (AttemptRecord, error)
+	// RecordFailure atomically adds failed attempt to key and returns updated record, failures older than window are forgotten first
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (AttemptRecord, error)
+	// Release takes back one failure of key, it undoes attempt that was counted before it turned out to be successful
+	Release(ctx context.Context, key string) error
+	// Lock blocks key until given time
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets failures and lock of key
+	Reset(ctx context.Context, key string) error
+}
+
+// defines MemoryStore that keeps counters in memory of one server instance, counters are lost on restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]AttemptRecord
+}
+
+// creates function that builds MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]AttemptRecord{}}
+}
+
+// creates method that gets record of key
+func (m *MemoryStore) Get(ctx context.Context, key string) (AttemptRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[key]
+	if !ok || !time.Now().Before(record.ExpiresAt) {
+		return AttemptRecord{Key: key}, nil
+	}
+	return record, nil
+}
+
+// creates method that adds failed attempt to key
+func (m *MemoryStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (AttemptRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// forgets old failures
+	record, ok := m.records[key]
+	if !ok || now.Sub(record.LastFailure) > window {
+		record = AttemptRecord{Key: key, LockedUntil: record.LockedUntil}
+	}
+	record.Failures++
+	record.PreviousFailure = record.LastFailure
+	record.LastFailure = now
+	record.ExpiresAt = later(now.Add(window), record.LockedUntil)
+	m.records[key] = record
+
+	// removes expired records when map grows
+	if len(m.records) > memoryStorePruneSize {
+		for k, r := range m.records {
+			if !now.Before(r.ExpiresAt) {
+				delete(m.records, k)
+			}
+		}
+	}
+	return record, nil
+}
+
+// creates method that takes back one failure of key
+func (m *MemoryStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[key]; ok && record.Failures > 0 {
+		record.Failures--
+		m.records[key] = record
+	}
+	return nil
+}
+
+// creates method that blocks key until given time
+func (m *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.records[key]
+	record.Key = key
+	record.LockedUntil = until
+	record.ExpiresAt = later(record.ExpiresAt, until)
+	m.records[key] = record
+	return nil
+}
+
+// creates method that forgets key
+func (m *MemoryStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+// creates function that gets later of two times
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
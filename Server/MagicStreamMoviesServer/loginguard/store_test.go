@@ -0,0 +1,86 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRecordFailure(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	window := 15 * time.Minute
+
+	tests := []struct {
+		name         string
+		failures     []time.Time
+		wantFailures int
+		wantPrevious time.Time
+	}{
+		{name: "first failure", failures: []time.Time{now}, wantFailures: 1},
+		{name: "failures in window add up", failures: []time.Time{now.Add(-2 * time.Minute), now.Add(-time.Minute), now},
+			wantFailures: 3, wantPrevious: now.Add(-time.Minute)},
+		{name: "failures older than window are forgotten", failures: []time.Time{now.Add(-time.Hour), now.Add(-30 * time.Minute), now},
+			wantFailures: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			var record AttemptRecord
+			for _, failure := range tt.failures {
+				var err error
+				if record, err = store.RecordFailure(ctx, "email:user@example.com", failure, window); err != nil {
+					t.Fatalf("RecordFailure returned error: %v", err)
+				}
+			}
+			if record.Failures != tt.wantFailures {
+				t.Errorf("Failures = %d, want %d", record.Failures, tt.wantFailures)
+			}
+			if !record.PreviousFailure.Equal(tt.wantPrevious) {
+				t.Errorf("PreviousFailure = %v, want %v", record.PreviousFailure, tt.wantPrevious)
+			}
+			if !record.LastFailure.Equal(now) {
+				t.Errorf("LastFailure = %v, want %v", record.LastFailure, now)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreReleaseLockAndReset(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	key := "ip:203.0.113.7"
+	now := time.Now()
+
+	store.RecordFailure(ctx, key, now, time.Minute)
+	store.RecordFailure(ctx, key, now, time.Minute)
+
+	// release takes back one failure and never goes below zero
+	for _, want := range []int{1, 0, 0} {
+		if err := store.Release(ctx, key); err != nil {
+			t.Fatalf("Release returned error: %v", err)
+		}
+		if record, _ := store.Get(ctx, key); record.Failures != want {
+			t.Errorf("Failures after Release = %d, want %d", record.Failures, want)
+		}
+	}
+
+	// lock outlives window of failures
+	until := now.Add(time.Hour)
+	if err := store.Lock(ctx, key, until); err != nil {
+		t.Fatalf("Lock returned error: %v", err)
+	}
+	if record, _ := store.Get(ctx, key); !record.LockedUntil.Equal(until) || record.ExpiresAt.Before(until) {
+		t.Errorf("record after Lock = %+v, want locked and kept until %v", record, until)
+	}
+
+	// reset forgets failures and lock
+	if err := store.Reset(ctx, key); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if record, _ := store.Get(ctx, key); record.Failures != 0 || !record.LockedUntil.IsZero() {
+		t.Errorf("record after Reset = %+v, want empty", record)
+	}
+}
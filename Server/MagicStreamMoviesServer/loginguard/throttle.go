@@ -0,0 +1,83 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"time"
+)
+
+// defines default password reset throttle settings
+const (
+	defaultMaxResetsPerAccount = 3
+	defaultMaxResetsPerIP      = 20
+	defaultResetWindow         = time.Hour
+)
+
+// defines reason of decision of throttle
+const ReasonRateLimited = "rate_limited"
+
+// defines Throttle that limits how often unauthenticated action, like sending password reset email, is done for one account
+// and from one client ip, it counts requests in attempt store so that limits are shared by all server instances
+type Throttle struct {
+	Store AttemptStore
+	// Name is prefix of store keys, it keeps counters of different actions apart
+	Name          string
+	MaxPerAccount int
+	MaxPerIP      int
+	// Window is how long requests are remembered after the last allowed one
+	Window time.Duration
+}
+
+// creates function that builds throttle of password reset emails with limits from PASSWORD_RESET_MAX_PER_EMAIL,
+// PASSWORD_RESET_MAX_PER_IP and PASSWORD_RESET_WINDOW_MINUTES env
+func NewPasswordResetThrottleFromEnv(store AttemptStore) *Throttle {
+	return &Throttle{
+		Store:         store,
+		Name:          "password_reset",
+		MaxPerAccount: envInt("PASSWORD_RESET_MAX_PER_EMAIL", defaultMaxResetsPerAccount),
+		MaxPerIP:      envInt("PASSWORD_RESET_MAX_PER_IP", defaultMaxResetsPerIP),
+		Window:        envDuration("PASSWORD_RESET_WINDOW_MINUTES", time.Minute, defaultResetWindow),
+	}
+}
+
+// creates method that counts request of account and client ip and tells if it may go on, refused requests are not counted
+// so that flood of refused requests does not extend wait of real user
+func (t *Throttle) Allow(ctx context.Context, email, ip string) (Decision, error) {
+	keys := []struct {
+		key   string
+		limit int
+	}{
+		{t.Name + ":" + accountKey(email), t.MaxPerAccount},
+		{t.Name + ":" + ipKey(ip), t.MaxPerIP},
+	}
+	now := time.Now()
+
+	// refuses request when one of limits is used up already
+	for _, k := range keys {
+		record, err := t.Store.Get(ctx, k.key)
+		if err != nil {
+			return Decision{}, err
+		}
+		if record.Failures >= k.limit {
+			return Decision{RetryAfter: t.retryAfter(record, now), Reason: ReasonRateLimited}, nil
+		}
+	}
+
+	// counts request, parallel requests that went over limit meanwhile are refused
+	for _, k := range keys {
+		record, err := t.Store.RecordFailure(ctx, k.key, now, t.Window)
+		if err != nil {
+			return Decision{}, err
+		}
+		if record.Failures > k.limit {
+			return Decision{RetryAfter: t.retryAfter(record, now), Reason: ReasonRateLimited}, nil
+		}
+	}
+	return Decision{Allowed: true}, nil
+}
+
+// creates method that gets wait until counted requests of record are forgotten
+func (t *Throttle) retryAfter(record AttemptRecord, now time.Time) time.Duration {
+	return max(record.LastFailure.Add(t.Window).Sub(now), time.Second)
+}
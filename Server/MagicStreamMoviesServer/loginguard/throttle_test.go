@@ -0,0 +1,113 @@
+// marks file as part of loginguard package
+package loginguard
+
+// imports packages
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// creates function that builds throttle with memory store and small limits
+func newTestThrottle() *Throttle {
+	return &Throttle{
+		Store:         NewMemoryStore(),
+		Name:          "password_reset",
+		MaxPerAccount: 2,
+		MaxPerIP:      3,
+		Window:        time.Hour,
+	}
+}
+
+// creates function that moves counted requests of key back in time, so that test does not wait for window to pass
+func ageRequests(throttle *Throttle, key string, by time.Duration) {
+	store := throttle.Store.(*MemoryStore)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	record := store.records[key]
+	record.LastFailure = record.LastFailure.Add(-by)
+	record.ExpiresAt = record.ExpiresAt.Add(-by)
+	store.records[key] = record
+}
+
+func TestThrottleAllow(t *testing.T) {
+	ctx := context.Background()
+	email, ip := "user@example.com", "203.0.113.7"
+
+	tests := []struct {
+		name        string
+		prepare     func(t *testing.T, throttle *Throttle)
+		wantAllowed bool
+	}{
+		{name: "first request", prepare: func(t *testing.T, throttle *Throttle) {}, wantAllowed: true},
+		{name: "email limit", prepare: func(t *testing.T, throttle *Throttle) {
+			allow(t, throttle, email, "198.51.100.1")
+			allow(t, throttle, email, "198.51.100.2")
+		}},
+		{name: "email is compared without case", prepare: func(t *testing.T, throttle *Throttle) {
+			allow(t, throttle, "USER@example.com", "198.51.100.1")
+			allow(t, throttle, "User@Example.com", "198.51.100.2")
+		}},
+		{name: "ip limit over many emails", prepare: func(t *testing.T, throttle *Throttle) {
+			for _, other := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+				allow(t, throttle, other, ip)
+			}
+		}},
+		{name: "requests are forgotten after window", prepare: func(t *testing.T, throttle *Throttle) {
+			allow(t, throttle, email, "198.51.100.1")
+			allow(t, throttle, email, "198.51.100.2")
+			ageRequests(throttle, throttle.Name+":"+accountKey(email), 2*time.Hour)
+		}, wantAllowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := newTestThrottle()
+			tt.prepare(t, throttle)
+			decision, err := throttle.Allow(ctx, email, ip)
+			if err != nil {
+				t.Fatalf("Allow returned error: %v", err)
+			}
+			if decision.Allowed != tt.wantAllowed {
+				t.Errorf("Allow = %+v, want allowed %v", decision, tt.wantAllowed)
+			}
+			if !decision.Allowed && (decision.Reason != ReasonRateLimited || decision.RetryAfter <= 0) {
+				t.Errorf("Allow = %+v, want rate limited with positive wait", decision)
+			}
+		})
+	}
+}
+
+func TestThrottleDoesNotCountRefusedRequests(t *testing.T) {
+	ctx := context.Background()
+	throttle := newTestThrottle()
+	email, ip := "user@example.com", "203.0.113.7"
+
+	for i := 0; i < 10; i++ {
+		throttle.Allow(ctx, email, ip)
+	}
+
+	// only allowed requests are counted, so that flood of refused requests does not extend wait
+	if record, _ := throttle.Store.Get(ctx, throttle.Name+":"+accountKey(email)); record.Failures != throttle.MaxPerAccount {
+		t.Errorf("email requests = %d, want %d", record.Failures, throttle.MaxPerAccount)
+	}
+	if record, _ := throttle.Store.Get(ctx, throttle.Name+":"+ipKey(ip)); record.Failures != throttle.MaxPerAccount {
+		t.Errorf("ip requests = %d, want %d", record.Failures, throttle.MaxPerAccount)
+	}
+
+	// counters of throttle are kept apart from counters of login guard
+	if record, _ := throttle.Store.Get(ctx, accountKey(email)); record.Failures != 0 {
+		t.Errorf("login failures = %d, want 0", record.Failures)
+	}
+}
+
+// creates function that requests throttle and fails test when request is refused
+func allow(t *testing.T, throttle *Throttle, email, ip string) {
+	t.Helper()
+	decision, err := throttle.Allow(context.Background(), email, ip)
+	if err != nil {
+		t.Fatalf("Allow returned error: %v", err)
+	}
+	if !decision.Allowed {
+		t.Fatalf("Allow(%q, %q) = %+v, want allowed", email, ip, decision)
+	}
+}
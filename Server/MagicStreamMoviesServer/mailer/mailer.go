@@ -0,0 +1,211 @@
+// marks file as part of mailer package
+package mailer
+
+// imports packages
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defines names of supported mailers
+const (
+	ProviderSMTP = "smtp"
+	ProviderFile = "file"
+	ProviderLog  = "log"
+)
+
+// defines default mailer settings
+const (
+	defaultSMTPPort = "587"
+	defaultMailDir  = "mail"
+	defaultFrom     = "MagicStream <no-reply@magicstream.local>"
+)
+
+// creates Message struct that holds plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// defines Mailer interface that sends emails
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// creates function that builds mailer configured with MAIL_PROVIDER env, log mailer is used when provider is not set so that flows work without mail service
+func NewMailerFromEnv() (Mailer, error) {
+	// gets sender address from env
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = defaultFrom
+	}
+
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_PROVIDER"))); provider {
+	case "", ProviderLog:
+		// log mailer writes live verification and password reset links to server log, anyone who reads log can take over accounts
+		if !isLocalDevelopment() {
+			log.Println("Warning: MAIL_PROVIDER is log outside local development, verification and password reset links are written to server log, set MAIL_PROVIDER to smtp")
+		}
+		return NewLogMailer(), nil
+
+	case ProviderFile:
+		// gets directory of email files from env
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = defaultMailDir
+		}
+		return NewFileMailer(dir, from), nil
+
+	case ProviderSMTP:
+		// gets smtp server settings from env
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			return nil, fmt.Errorf("could not read SMTP_HOST")
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = defaultSMTPPort
+		}
+		return NewSMTPMailer(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+
+	default:
+		return nil, fmt.Errorf("unknown mail provider %q", provider)
+	}
+}
+
+// creates function that tells if server runs in local development, gin runs in debug mode and links of emails point at local host
+func isLocalDevelopment() bool {
+	if os.Getenv("GIN_MODE") == "release" {
+		return false
+	}
+	for _, name := range []string{"PASSWORD_RESET_URL", "VERIFY_EMAIL_URL"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		link, err := url.Parse(value)
+		if err != nil {
+			return false
+		}
+		switch host := link.Hostname(); host {
+		case "localhost", "127.0.0.1", "::1":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// defines SMTPMailer that sends emails through smtp server
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// creates function that builds SMTPMailer, username may be empty for servers without authentication
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{host: host, port: port, username: username, password: password, from: from}
+}
+
+// creates method that sends email through smtp server
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	// defines authentication, smtp.PlainAuth refuses to send password over unencrypted connection to remote host
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	// sends email in background so that context can cancel waiting for slow server
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.host, m.port), auth, address(m.from), []string{msg.To}, format(m.from, msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// defines FileMailer that writes every email into its own file, it is used to read emails locally
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// creates function that builds FileMailer
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+// creates method that writes email into .eml file in mail directory
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitize(msg.To))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, format(m.from, msg), 0o600); err != nil {
+		return err
+	}
+	log.Printf("Mail to %s written to %s", msg.To, path)
+	return nil
+}
+
+// defines LogMailer that prints emails to server log
+type LogMailer struct{}
+
+// creates function that builds LogMailer
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// creates method that prints email to server log
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s\nSubject: %s\n\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// creates function that formats email with headers
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// creates function that gets bare email address from sender, e.g. "MagicStream <no-reply@x.com>" becomes "no-reply@x.com"
+func address(from string) string {
+	if start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">"); start >= 0 && end > start {
+		return from[start+1 : end]
+	}
+	return from
+}
+
+// creates function that replaces characters that can not be used in file names
+func sanitize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, value)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +17,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/jobs"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/mailer"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/routes"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
 )
 
 // creates main function that runs program
@@ -35,6 +41,11 @@ func main() {
 		log.Println("Warning: unable to find .env file")
 	}
 
+	// loads keys that sign tokens, server refuses to start without strong keys
+	if err := utils.InitSigningKeysFromEnv(); err != nil {
+		log.Fatalf("Failed to load token signing keys: %v", err)
+	}
+
 	// defines allowed origins
 	allowedOrigins := os.Getenv("ALLOWED_ORIGINS")
 
@@ -52,13 +63,27 @@ func main() {
 		log.Println("Allowed Origin: http://localhost:5173")
 	}
 
+	// defines proxies whose X-Forwarded-For header is trusted, by default none is trusted so that client ip used by login guard
+	// and sessions is address of connection and can not be spoofed
+	var trustedProxies []string
+	if value := os.Getenv("TRUSTED_PROXIES"); value != "" {
+		for _, proxy := range strings.Split(value, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				trustedProxies = append(trustedProxies, proxy)
+			}
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// configures cors - allows cross-origin requests to communicate frontend and backend
 	config := cors.Config{}
 	config.AllowOrigins = origins
 	config.AllowMethods = []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"}
 	//config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key", "X-Token-Delivery"}
+	config.ExposeHeaders = []string{"Content-Length", "Retry-After"}
 	config.AllowCredentials = true
 	config.MaxAge = 12 * time.Hour
 	// sets router to use cors
@@ -73,6 +98,17 @@ func main() {
 		log.Fatalf("Failed to reach server: %v", err)
 	}
 
+	// updates documents stored by older versions of application, it runs first because indexes rely on migrated data
+	ratingPriorMean, _ := utils.RatingPrior()
+	if err := database.RunMigrations(client, ratingPriorMean); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// creates database indexes
+	if err := database.CreateIndexes(client); err != nil {
+		log.Fatalf("Failed to create database indexes: %v", err)
+	}
+
 	// in any case when function ends , closes connection
 	defer func() {
 		err := client.Disconnect(context.Background())
@@ -82,9 +118,60 @@ func main() {
 
 	}()
 
+	// creates review ranking classifier configured with LLM_PROVIDER env
+	classifier, err := llm.NewRankingClassifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create ranking classifier: %v", err)
+	}
+
+	// defines number of review queue workers, can be set with REVIEW_WORKERS env
+	reviewWorkers := 2
+	if workersStr := os.Getenv("REVIEW_WORKERS"); workersStr != "" {
+		if workers, err := strconv.Atoi(workersStr); err == nil {
+			reviewWorkers = workers
+		}
+	}
+
+	// starts review queue that ranks reviews in background, keyword classifier ranks reviews that classifier failed on after all retries
+	reviewQueue := jobs.NewReviewQueue(client, classifier, llm.NewKeywordRankingClassifier(), reviewWorkers)
+	reviewQueue.Start()
+	defer reviewQueue.Stop()
+
+	// creates embedder of similar movies configured with EMBEDDING_PROVIDER env
+	embedder, err := llm.NewEmbedderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create embedder: %v", err)
+	}
+
+	// starts embedding sync that builds embeddings of new and changed movies in background
+	embeddingSync := jobs.NewEmbeddingSync(client, embedder)
+	embeddingSync.Start()
+	defer embeddingSync.Stop()
+
+	// creates parser of recommendation prompts configured with LLM_PROVIDER env
+	promptParser, err := llm.NewPromptParserFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create recommendation prompt parser: %v", err)
+	}
+
+	// creates mailer of verification and password reset emails configured with MAIL_PROVIDER env
+	mail, err := mailer.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to create mailer: %v", err)
+	}
+
+	// creates guard of login attempts with store configured with LOGIN_ATTEMPT_STORE env
+	guard, err := loginguard.NewGuardFromEnv(client)
+	if err != nil {
+		log.Fatalf("Failed to create login guard: %v", err)
+	}
+
+	// creates throttle of password reset emails, it keeps counters in the same store as login guard
+	resetThrottle := loginguard.NewPasswordResetThrottleFromEnv(guard.Store)
+
 	// sets up routes
-	routes.SetUpUnprotectedRoutes(router, client)
-	routes.SetupProtectedRoutes(router, client)
+	routes.SetUpUnprotectedRoutes(router, client, mail, guard, resetThrottle)
+	routes.SetupProtectedRoutes(router, client, reviewQueue, embeddingSync, embedder, promptParser, mail, guard)
 
 	// displays error if occurs
 	if err := router.Run(":8080"); err != nil {
@@ -7,10 +7,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-// creates function that handles authentication middleware
-func AuthMiddleWare() gin.HandlerFunc {
+// creates function that handles authentication middleware, rejects tokens that have been revoked on logout, password change or session revocation
+func AuthMiddleWare(client *mongo.Client) gin.HandlerFunc {
 	// returns anonymous function that works with gin context
 	return func(c *gin.Context) {
 
@@ -40,9 +41,24 @@ func AuthMiddleWare() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		// sets user id and role to context
+
+		// checks if token is on denylist
+		revoked, err := utils.IsTokenRevoked(c, client, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		// sets user id, role and session id to context
 		c.Set("userId", claims.UserId)
 		c.Set("role", claims.Role)
+		c.Set("sessionId", claims.SessionId)
 		// passes request to next middleware handler
 		c.Next()
 
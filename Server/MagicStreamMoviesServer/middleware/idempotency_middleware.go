@@ -0,0 +1,163 @@
+// marks file as part of middleware package
+package middleware
+
+// imports packages
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// defines states of stored idempotency key
+const (
+	idempotencyProcessing = "processing"
+	idempotencyCompleted  = "completed"
+)
+
+// defines idempotencyRecord struct that is stored for every used idempotency key
+type idempotencyRecord struct {
+	Key            string    `bson:"key"`
+	RequestHash    string    `bson:"request_hash"`
+	Status         string    `bson:"status"`
+	ResponseStatus int       `bson:"response_status"`
+	ResponseBody   []byte    `bson:"response_body"`
+	ContentType    string    `bson:"content_type"`
+	CreatedAt      time.Time `bson:"created_at"`
+}
+
+// defines responseRecorder that keeps copy of response body written by handler
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+// creates method that writes response body and keeps its copy
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+// creates function that makes requests with Idempotency-Key header safe to retry, repeated request gets stored response of the first one
+func IdempotencyMiddleware(client *mongo.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// gets idempotency key from header, requests without header are handled as usual
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// reads request body and puts it back so that handler can read it
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// scopes key by user, so that users can not replay responses of each other
+		userId, _ := utils.GetUserIdFromContext(c)
+		scopedKey := userId + ":" + c.Request.Method + ":" + c.FullPath() + ":" + key
+
+		// hashes request body to detect reuse of key with another request
+		hash := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(hash[:])
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 100*time.Second)
+		defer cancel()
+
+		// opens idempotency keys collection from database
+		var idempotencyCollection *mongo.Collection = database.OpenCollection("idempotency_keys", client)
+
+		// reserves key, unique index makes sure that only one request can reserve it
+		_, err = idempotencyCollection.InsertOne(ctx, idempotencyRecord{
+			Key:         scopedKey,
+			RequestHash: requestHash,
+			Status:      idempotencyProcessing,
+			CreatedAt:   time.Now(),
+		})
+		if err != nil {
+			if !mongo.IsDuplicateKeyError(err) {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking idempotency key"})
+				c.Abort()
+				return
+			}
+
+			// key was already used, gets stored record
+			var record idempotencyRecord
+			if err := idempotencyCollection.FindOne(ctx, bson.M{"key": scopedKey}).Decode(&record); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking idempotency key"})
+				c.Abort()
+				return
+			}
+
+			switch {
+			// key is reused with another request body
+			case record.RequestHash != requestHash:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			// first request is still being handled
+			case record.Status != idempotencyCompleted:
+				c.JSON(http.StatusConflict, gin.H{"error": "Request with this Idempotency-Key is still being processed"})
+			// replays stored response of the first request
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.ResponseStatus, record.ContentType, record.ResponseBody)
+			}
+			c.Abort()
+			return
+		}
+
+		// records response written by handler
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// releases key when response is not stored, also when handler panics, so that retries are not refused until key expires,
+		// panic keeps going up to recovery middleware after key is released
+		stored := false
+		defer func() {
+			if !stored {
+				releaseIdempotencyKey(idempotencyCollection, scopedKey)
+			}
+		}()
+
+		// passes request to next handler
+		c.Next()
+
+		// releases key after server errors so that client can retry
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		// creates new context because request context can already be cancelled
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer saveCancel()
+
+		// stores response, key is released when it can not be stored
+		_, err = idempotencyCollection.UpdateOne(saveCtx, bson.M{"key": scopedKey}, bson.M{"$set": bson.M{
+			"status":          idempotencyCompleted,
+			"response_status": recorder.Status(),
+			"response_body":   recorder.body.Bytes(),
+			"content_type":    recorder.Header().Get("Content-Type"),
+		}})
+		stored = err == nil
+	}
+}
+
+// creates function that removes reserved idempotency key, new context is used because request context can already be cancelled
+func releaseIdempotencyKey(idempotencyCollection *mongo.Collection, scopedKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _ = idempotencyCollection.DeleteOne(ctx, bson.M{"key": scopedKey})
+}
@@ -0,0 +1,37 @@
+// marks file as part of middleware package
+package middleware
+
+// imports packages
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+)
+
+// creates function that allows request only for users with one of required roles, must run after AuthMiddleWare
+func RequireRole(roles ...string) gin.HandlerFunc {
+	// returns anonymous function that works with gin context
+	return func(c *gin.Context) {
+		// gets role that AuthMiddleWare has set to context
+		role, err := utils.GetRoleFromContext(c)
+
+		// if role is not found, user is not authenticated
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		// if user is authenticated but lacks required role, uses JSON to return forbidden status
+		if !slices.Contains(roles, role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "User does not have required role", "required_roles": roles})
+			c.Abort()
+			return
+		}
+
+		// passes request to next middleware handler
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+// marks file as part of middleware package
+package middleware
+
+// imports packages
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/utils"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// creates function that allows request only for users who have verified email, must run after AuthMiddleWare,
+// users created before email verification was added are marked verified by database.RunMigrations at startup
+func RequireVerifiedEmail(client *mongo.Client) gin.HandlerFunc {
+	// returns anonymous function that works with gin context
+	return func(c *gin.Context) {
+		// gets user id that AuthMiddleWare has set to context
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		// creates special context that cancels request if timeout occurs
+		ctx, cancel := context.WithTimeout(c, 10*time.Second)
+		defer cancel()
+
+		// checks email in database, so that user gets access right after opening verification link
+		count, err := database.OpenCollection("users", client).CountDocuments(ctx, bson.M{"user_id": userId, "email_verified": true})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while checking email verification"})
+			c.Abort()
+			return
+		}
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Email address is not verified", "resend_url": "/verify-email/resend"})
+			c.Abort()
+			return
+		}
+
+		// passes request to next middleware handler
+		c.Next()
+	}
+}
@@ -0,0 +1,36 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// defines types of audit events
+const (
+	AuditLoginFailed     = "login_failed"
+	AuditLoginSucceeded  = "login_succeeded"
+	AuditAccountLocked   = "account_locked"
+	AuditIPLocked        = "ip_locked"
+	AuditAccountUnlocked = "account_unlocked"
+)
+
+// creates AuditEvent struct that stores security related event, actor is user id of admin for events caused by admin
+type AuditEvent struct {
+	ID        bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Type      string         `bson:"type" json:"type"`
+	Email     string         `bson:"email,omitempty" json:"email,omitempty"`
+	IP        string         `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserID    string         `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Actor     string         `bson:"actor,omitempty" json:"actor,omitempty"`
+	Details   map[string]any `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+}
+
+// creates LoginUnlock struct that admin sends to unlock account or client ip
+type LoginUnlock struct {
+	Email string `json:"email" validate:"omitempty,email"`
+	IP    string `json:"ip" validate:"omitempty,ip"`
+}
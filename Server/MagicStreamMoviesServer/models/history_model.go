@@ -0,0 +1,36 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// creates ViewingProgress struct that stores how far user has watched movie
+type ViewingProgress struct {
+	ID              bson.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID          string        `bson:"user_id" json:"user_id"`
+	ImdbID          string        `bson:"imdb_id" json:"imdb_id"`
+	PositionSeconds float64       `bson:"position_seconds" json:"position_seconds"`
+	DurationSeconds float64       `bson:"duration_seconds" json:"duration_seconds"`
+	Completed       bool          `bson:"completed" json:"completed"`
+	CompletedAt     *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	LastWatchedAt   time.Time     `bson:"last_watched_at" json:"last_watched_at"`
+}
+
+// creates HistoryEntry struct that holds watched movie together with resume point
+type HistoryEntry struct {
+	Movie           Movie     `bson:"movie" json:"movie"`
+	PositionSeconds float64   `bson:"position_seconds" json:"position_seconds"`
+	DurationSeconds float64   `bson:"duration_seconds" json:"duration_seconds"`
+	Completed       bool      `bson:"completed" json:"completed"`
+	LastWatchedAt   time.Time `bson:"last_watched_at" json:"last_watched_at"`
+}
+
+// creates HistoryPage struct that holds one page of user viewing history
+type HistoryPage struct {
+	Items []HistoryEntry `json:"items"`
+	PageMetadata
+}
@@ -0,0 +1,18 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+)
+
+// creates MovieEmbedding struct that stores vector of movie title, genres and admin review next to movie
+type MovieEmbedding struct {
+	ImdbID string `bson:"imdb_id" json:"imdb_id"`
+	// Provider names embedder that built vector, vectors of different embedders can not be compared
+	Provider string `bson:"provider" json:"provider"`
+	// TextHash is hash of embedded text, it shows when movie has changed and vector has to be rebuilt
+	TextHash  string    `bson:"text_hash" json:"text_hash"`
+	Vector    []float32 `bson:"vector" json:"-"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
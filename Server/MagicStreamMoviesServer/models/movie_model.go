@@ -28,4 +28,9 @@ type Movie struct {
 	Genre       []Genre       `bson:"genre" json:"genre" validate:"required,dive"`
 	AdminReview string        `bson:"admin_review" json:"admin_review"`
 	Ranking     Ranking       `bson:"ranking" json:"ranking" validate:"required"`
+	// ReviewStatus is pending while admin review is being ranked in background
+	ReviewStatus string `bson:"review_status,omitempty" json:"review_status,omitempty"`
+	ReviewJobID  string `bson:"review_job_id,omitempty" json:"review_job_id,omitempty"`
+	// AudienceScore aggregates star ratings submitted by users
+	AudienceScore AudienceScore `bson:"audience_score" json:"audience_score"`
 }
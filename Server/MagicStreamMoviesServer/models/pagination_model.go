@@ -0,0 +1,18 @@
+// marks file as part of models package
+package models
+
+// creates PageMetadata struct that describes current page of paginated response
+type PageMetadata struct {
+	Page       int64  `json:"page"`
+	Limit      int64  `json:"limit"`
+	TotalCount int64  `json:"total_count"`
+	TotalPages int64  `json:"total_pages"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+}
+
+// creates MoviePage struct that holds one page of movies together with page metadata
+type MoviePage struct {
+	Movies []Movie `json:"movies"`
+	PageMetadata
+}
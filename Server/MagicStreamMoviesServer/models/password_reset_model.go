@@ -0,0 +1,36 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// creates PasswordReset struct that stores hash of password reset token, token can be used once before it expires
+type PasswordReset struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	UserID    string        `bson:"user_id"`
+	TokenHash string        `bson:"token_hash"`
+	ExpiresAt time.Time     `bson:"expires_at"`
+	UsedAt    *time.Time    `bson:"used_at,omitempty"`
+	CreatedAt time.Time     `bson:"created_at"`
+}
+
+// creates PasswordChange struct that holds request of logged in user to change password
+type PasswordChange struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// creates PasswordForgot struct that holds request to send password reset email
+type PasswordForgot struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// creates PasswordResetRequest struct that holds reset token from email and new password
+type PasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
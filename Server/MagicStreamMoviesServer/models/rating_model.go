@@ -0,0 +1,27 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// creates Rating struct that stores star rating submitted by user
+type Rating struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID    string        `bson:"user_id" json:"user_id"`
+	ImdbID    string        `bson:"imdb_id" json:"imdb_id"`
+	Rating    int           `bson:"rating" json:"rating" validate:"required,min=1,max=10"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// creates AudienceScore struct that holds aggregates of user ratings of movie, they are updated incrementally on every rating
+type AudienceScore struct {
+	Count         int64   `bson:"count" json:"count"`
+	Sum           int64   `bson:"sum" json:"-"`
+	Mean          float64 `bson:"mean" json:"mean"`
+	WeightedScore float64 `bson:"weighted_score" json:"weighted_score"`
+}
@@ -0,0 +1,13 @@
+// marks file as part of models package
+package models
+
+// creates RecommendationQuery struct that holds filters parsed from free text recommendation prompt
+type RecommendationQuery struct {
+	Genres   []string `json:"genres"`
+	Rankings []string `json:"rankings"`
+}
+
+// creates method that checks if query has no filters
+func (q RecommendationQuery) IsEmpty() bool {
+	return len(q.Genres) == 0 && len(q.Rankings) == 0
+}
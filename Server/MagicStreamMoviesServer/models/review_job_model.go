@@ -0,0 +1,42 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// defines states of review ranking
+const (
+	ReviewStatusPending = "pending"
+	ReviewStatusRanked  = "ranked"
+	ReviewStatusFailed  = "failed"
+	// ReviewStatusSuperseded means that review was changed again before job finished, its ranking is left to newer job
+	ReviewStatusSuperseded = "superseded"
+)
+
+// defines kinds of reviews that can be classified
+const (
+	ReviewKindAdmin = "admin_review"
+	ReviewKindUser  = "user_review"
+)
+
+// creates ReviewJob struct that describes background classification of review
+type ReviewJob struct {
+	ID       bson.ObjectID `bson:"_id,omitempty" json:"job_id"`
+	Kind     string        `bson:"kind" json:"kind"`
+	TargetID string        `bson:"target_id" json:"target_id"`
+	// UserID is id of user who wrote review, only that user and admins can read job
+	UserID    string   `bson:"user_id,omitempty" json:"-"`
+	Review    string   `bson:"review" json:"-"`
+	Status    string   `bson:"status" json:"status"`
+	Attempts  int      `bson:"attempts" json:"attempts"`
+	LastError string   `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	Ranking   *Ranking `bson:"ranking,omitempty" json:"ranking,omitempty"`
+	// LockedUntil is set while job is processed by one worker or waits for retry, other workers and instances skip job until then
+	LockedUntil *time.Time `bson:"locked_until,omitempty" json:"-"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// creates Review struct that stores review written by user
+type Review struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"review_id"`
+	ImdbID       string        `bson:"imdb_id" json:"imdb_id"`
+	UserID       string        `bson:"user_id" json:"user_id"`
+	AuthorName   string        `bson:"author_name" json:"author_name"`
+	Text         string        `bson:"text" json:"text" validate:"required,min=2,max=5000"`
+	Ranking      *Ranking      `bson:"ranking,omitempty" json:"ranking,omitempty"`
+	ReviewStatus string        `bson:"review_status" json:"review_status"`
+	ReviewJobID  string        `bson:"review_job_id" json:"review_job_id"`
+	Hidden       bool          `bson:"hidden" json:"hidden"`
+	HiddenBy     string        `bson:"hidden_by,omitempty" json:"hidden_by,omitempty"`
+	CreatedAt    time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// creates ReviewPage struct that holds one page of movie reviews
+type ReviewPage struct {
+	Reviews []Review `json:"reviews"`
+	PageMetadata
+}
@@ -0,0 +1,35 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// defines reasons why session was revoked
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedByUser         = "revoked_by_user"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedTokenReuse     = "refresh_token_reuse"
+)
+
+// creates Session struct that stores login of user on one device, only hash of current refresh token is stored
+type Session struct {
+	ID               bson.ObjectID `bson:"_id,omitempty" json:"session_id"`
+	UserID           string        `bson:"user_id" json:"-"`
+	RefreshTokenHash string        `bson:"refresh_token_hash" json:"-"`
+	// Rotations counts how many times refresh token has been rotated
+	Rotations     int        `bson:"rotations" json:"rotations"`
+	UserAgent     string     `bson:"user_agent" json:"user_agent"`
+	IP            string     `bson:"ip" json:"ip"`
+	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
+	LastUsedAt    time.Time  `bson:"last_used_at" json:"last_used_at"`
+	ExpiresAt     time.Time  `bson:"expires_at" json:"expires_at"`
+	RevokedAt     *time.Time `bson:"revoked_at,omitempty" json:"-"`
+	RevokedReason string     `bson:"revoked_reason,omitempty" json:"-"`
+	// Current marks session that request was made from
+	Current bool `bson:"-" json:"current"`
+}
@@ -19,9 +19,10 @@ type User struct {
 	Role            string        `json:"role" bson:"role" validate:"oneof=ADMIN USER"`
 	CreatedAt       time.Time     `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time     `json:"update_at" bson:"update_at"`
-	Token           string        `json:"token" bson:"token"`
-	RefreshToken    string        `json:"refresh_token" bson:"refresh_token"`
 	FavouriteGenres []Genre       `json:"favourite_genres" bson:"favourite_genres" validate:"required,dive"`
+	// EmailVerified is set once user opens link from verification email
+	EmailVerified   bool       `json:"email_verified" bson:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" bson:"email_verified_at,omitempty"`
 }
 
 // creates UserLogin struct
@@ -32,12 +33,39 @@ type UserLogin struct {
 
 // creates userResponse struct
 type UserResponse struct {
-	UserId          string  `json:"user_id"`
-	FirstName       string  `json:"first_name"`
-	LastName        string  `json:"last_name"`
-	Email           string  `json:"email"`
-	Role            string  `json:"role"`
-	Token           string  `json:"token"`
-	RefreshToken    string  `json:"refresh_token"`
+	UserId    string `json:"user_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	// Token and RefreshToken are only sent to clients that ask for tokens in response body
+	Token           string  `json:"token,omitempty"`
+	RefreshToken    string  `json:"refresh_token,omitempty"`
 	FavouriteGenres []Genre `json:"favourite_genres"`
+	EmailVerified   bool    `json:"email_verified"`
+}
+
+// creates UserProfile struct that holds user data that user can see and edit
+type UserProfile struct {
+	UserId          string    `json:"user_id" bson:"user_id"`
+	FirstName       string    `json:"first_name" bson:"first_name"`
+	LastName        string    `json:"last_name" bson:"last_name"`
+	Email           string    `json:"email" bson:"email"`
+	Role            string    `json:"role" bson:"role"`
+	FavouriteGenres []Genre   `json:"favourite_genres" bson:"favourite_genres"`
+	EmailVerified   bool      `json:"email_verified" bson:"email_verified"`
+	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time `json:"update_at" bson:"update_at"`
+}
+
+// creates GenreSelection struct that holds genre picked by user, only genre id is trusted and genre name is taken from genres collection
+type GenreSelection struct {
+	GenreID int `json:"genre_id" validate:"required"`
+}
+
+// creates UserProfileUpdate struct that holds fields of profile update, fields that are not sent stay unchanged
+type UserProfileUpdate struct {
+	FirstName       *string           `json:"first_name" validate:"omitempty,min=2,max=100"`
+	LastName        *string           `json:"last_name" validate:"omitempty,min=2,max=100"`
+	FavouriteGenres *[]GenreSelection `json:"favourite_genres" validate:"omitempty,max=50,dive"`
 }
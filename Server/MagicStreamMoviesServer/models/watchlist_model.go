@@ -0,0 +1,29 @@
+// marks file as part of models package
+package models
+
+// imports packages
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// creates WatchlistItem struct that stores movie saved by user for later
+type WatchlistItem struct {
+	ID      bson.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID  string        `bson:"user_id" json:"user_id"`
+	ImdbID  string        `bson:"imdb_id" json:"imdb_id"`
+	AddedAt time.Time     `bson:"added_at" json:"added_at"`
+}
+
+// creates WatchlistEntry struct that holds saved movie together with time when it was saved
+type WatchlistEntry struct {
+	Movie   Movie     `bson:"movie" json:"movie"`
+	AddedAt time.Time `bson:"added_at" json:"added_at"`
+}
+
+// creates WatchlistPage struct that holds one page of user watchlist
+type WatchlistPage struct {
+	Items []WatchlistEntry `json:"items"`
+	PageMetadata
+}
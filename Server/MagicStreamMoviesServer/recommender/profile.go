@@ -0,0 +1,276 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"context"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines settings of profile signals
+const (
+	// likedRating is the lowest user rating that counts as liking movie
+	likedRating = 7
+	// neutralRating is the middle of 1-10 rating scale
+	neutralRating = 5.5
+	// favouriteGenreAffinity, watchedGenreAffinity and completedGenreAffinity are added to genres of favourite, watched and fully watched movies
+	favouriteGenreAffinity = 1.0
+	watchedGenreAffinity   = 0.5
+	completedGenreAffinity = 0.75
+	// maxCandidates and popularCandidates limit how many movies are scored
+	maxCandidates     = 1000
+	popularCandidates = 100
+)
+
+// creates function that loads taste profile of user from favourite genres, ratings, viewing history and ratings of users with similar taste
+func LoadProfile(ctx context.Context, client *mongo.Client, userId string, favouriteGenres []string) (Profile, error) {
+	profile := Profile{
+		GenreAffinity: map[string]float64{},
+		CoOccurrence:  map[string]float64{},
+		Seen:          map[string]bool{},
+	}
+
+	// adds favourite genres
+	for _, genre := range favouriteGenres {
+		profile.GenreAffinity[genre] += favouriteGenreAffinity
+	}
+
+	// gets ratings of user
+	var ratings []models.Rating
+	if err := findAll(ctx, database.OpenCollection("ratings", client), bson.M{"user_id": userId}, &ratings); err != nil {
+		return profile, err
+	}
+
+	// gets viewing history of user
+	var history []models.ViewingProgress
+	if err := findAll(ctx, database.OpenCollection("viewing_history", client), bson.M{"user_id": userId}, &history); err != nil {
+		return profile, err
+	}
+
+	// defines affinity that every seen movie adds to its genres and movies that user liked
+	movieAffinity := map[string]float64{}
+	liked := map[string]bool{}
+	for _, progress := range history {
+		profile.Seen[progress.ImdbID] = true
+		movieAffinity[progress.ImdbID] = watchedGenreAffinity
+		if progress.Completed {
+			movieAffinity[progress.ImdbID] = completedGenreAffinity
+			liked[progress.ImdbID] = true
+		}
+	}
+	// own rating tells more than watching, so it replaces affinity of watched movie
+	for _, rating := range ratings {
+		profile.Seen[rating.ImdbID] = true
+		movieAffinity[rating.ImdbID] = (float64(rating.Rating) - neutralRating) / (10 - neutralRating)
+		liked[rating.ImdbID] = rating.Rating >= likedRating
+	}
+
+	// adds affinity of seen movies to their genres
+	if len(movieAffinity) > 0 {
+		var seenMovies []models.Movie
+		err := findAll(ctx, database.OpenCollection("movies", client),
+			bson.M{"imdb_id": bson.M{"$in": keys(movieAffinity)}}, &seenMovies,
+			options.Find().SetProjection(bson.M{"imdb_id": 1, "genre": 1}))
+		if err != nil {
+			return profile, err
+		}
+		for _, movie := range seenMovies {
+			for _, genre := range movie.Genre {
+				profile.GenreAffinity[genre.GenreName] += movieAffinity[movie.ImdbID]
+			}
+		}
+	}
+
+	// defines movies that user liked, they are seeds of co-occurrence
+	var seeds []string
+	for id, ok := range liked {
+		if ok {
+			seeds = append(seeds, id)
+		}
+	}
+	coOccurrence, err := loadCoOccurrence(ctx, client, userId, seeds)
+	if err != nil {
+		return profile, err
+	}
+	profile.CoOccurrence = coOccurrence
+
+	return profile, nil
+}
+
+// creates function that counts how many users who liked the same movies as user also liked other movies, scales counts to [0, 1] range
+func loadCoOccurrence(ctx context.Context, client *mongo.Client, userId string, seeds []string) (map[string]float64, error) {
+	scores := map[string]float64{}
+	if len(seeds) == 0 {
+		return scores, nil
+	}
+
+	ratingCollection := database.OpenCollection("ratings", client)
+	historyCollection := database.OpenCollection("viewing_history", client)
+
+	// defines filters of liked seed movies and of liked other movies
+	likedRatingFilter := bson.M{"rating": bson.M{"$gte": likedRating}}
+	completedFilter := bson.M{"completed": true}
+
+	// finds users who liked seed movies
+	neighbours := map[string]bool{}
+	for _, source := range []struct {
+		collection *mongo.Collection
+		filter     bson.M
+	}{{ratingCollection, likedRatingFilter}, {historyCollection, completedFilter}} {
+		filter := bson.M{"imdb_id": bson.M{"$in": seeds}, "user_id": bson.M{"$ne": userId}}
+		for key, value := range source.filter {
+			filter[key] = value
+		}
+		values, err := source.collection.Distinct(ctx, "user_id", filter).Raw()
+		if err != nil {
+			return nil, err
+		}
+		elements, err := values.Values()
+		if err != nil {
+			return nil, err
+		}
+		for _, element := range elements {
+			if id, ok := element.StringValueOK(); ok {
+				neighbours[id] = true
+			}
+		}
+	}
+	if len(neighbours) == 0 {
+		return scores, nil
+	}
+
+	// finds other movies liked by those users, every user counts once per movie
+	likedBy := map[string]map[string]bool{}
+	for _, source := range []struct {
+		collection *mongo.Collection
+		filter     bson.M
+	}{{ratingCollection, likedRatingFilter}, {historyCollection, completedFilter}} {
+		match := bson.M{"user_id": bson.M{"$in": keys(neighbours)}, "imdb_id": bson.M{"$nin": seeds}}
+		for key, value := range source.filter {
+			match[key] = value
+		}
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: match}},
+			{{Key: "$group", Value: bson.M{"_id": "$imdb_id", "users": bson.M{"$addToSet": "$user_id"}}}},
+		}
+		cursor, err := source.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		var groups []struct {
+			ImdbID string   `bson:"_id"`
+			Users  []string `bson:"users"`
+		}
+		if err := cursor.All(ctx, &groups); err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if likedBy[group.ImdbID] == nil {
+				likedBy[group.ImdbID] = map[string]bool{}
+			}
+			for _, user := range group.Users {
+				likedBy[group.ImdbID][user] = true
+			}
+		}
+	}
+
+	// scales counts by highest count
+	highest := 0
+	for _, users := range likedBy {
+		highest = max(highest, len(users))
+	}
+	for id, users := range likedBy {
+		scores[id] = float64(len(users)) / float64(highest)
+	}
+
+	return scores, nil
+}
+
+// creates function that loads movies worth scoring: movies of genres user likes, movies liked by similar users and most popular movies
+func LoadCandidates(ctx context.Context, client *mongo.Client, profile Profile) ([]models.Movie, error) {
+	movieCollection := database.OpenCollection("movies", client)
+
+	// defines filter that skips seen movies
+	unseen := bson.M{"$nin": keys(profile.Seen)}
+
+	// finds personal candidates, users without liked genres and co-occurring movies get only popular ones, best rated movies are taken
+	// first so that candidates do not depend on order in which movies are stored
+	candidatesById := map[string]models.Movie{}
+	var personal []models.Movie
+	if filter, ok := personalFilter(profile); ok {
+		filter["imdb_id"] = unseen
+		personalOptions := options.Find().
+			SetSort(bson.D{{Key: "audience_score.weighted_score", Value: -1}, {Key: "imdb_id", Value: 1}}).
+			SetLimit(maxCandidates)
+		if err := findAll(ctx, movieCollection, filter, &personal, personalOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	// finds popular candidates, they help users with little history
+	var popular []models.Movie
+	err := findAll(ctx, movieCollection, bson.M{"imdb_id": unseen}, &popular,
+		options.Find().SetSort(bson.D{{Key: "audience_score.count", Value: -1}}).SetLimit(popularCandidates))
+	if err != nil {
+		return nil, err
+	}
+
+	// merges candidates
+	candidates := make([]models.Movie, 0, len(personal)+len(popular))
+	for _, movie := range append(personal, popular...) {
+		if _, ok := candidatesById[movie.ImdbID]; !ok {
+			candidatesById[movie.ImdbID] = movie
+			candidates = append(candidates, movie)
+		}
+	}
+
+	return candidates, nil
+}
+
+// creates function that builds filter of movies with genres user likes or liked by users with similar taste, returns false
+// when profile has neither, e.g. for new user or user who only gave low ratings, because empty $in list matches nothing
+func personalFilter(profile Profile) (bson.M, bool) {
+	var branches bson.A
+
+	// defines genres that user likes
+	likedGenres := []string{}
+	for genre, affinity := range profile.GenreAffinity {
+		if affinity > 0 {
+			likedGenres = append(likedGenres, genre)
+		}
+	}
+	if len(likedGenres) > 0 {
+		branches = append(branches, bson.M{"genre.genre_name": bson.M{"$in": likedGenres}})
+	}
+	if len(profile.CoOccurrence) > 0 {
+		branches = append(branches, bson.M{"imdb_id": bson.M{"$in": keys(profile.CoOccurrence)}})
+	}
+
+	if len(branches) == 0 {
+		return nil, false
+	}
+	return bson.M{"$or": branches}, true
+}
+
+// creates function that finds all documents matching filter and decodes them into result
+func findAll(ctx context.Context, collection *mongo.Collection, filter bson.M, result any, opts ...options.Lister[options.FindOptions]) error {
+	cursor, err := collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, result)
+}
+
+// creates function that gets keys of map
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestPersonalFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    Profile
+		wantOk     bool
+		wantGenres int
+	}{
+		{name: "new user", profile: Profile{}, wantOk: false},
+		{name: "only low ratings", profile: Profile{GenreAffinity: map[string]float64{"Drama": -0.8, "Horror": 0}}, wantOk: false},
+		{name: "liked genre", profile: Profile{GenreAffinity: map[string]float64{"Drama": 1, "Horror": -1}}, wantOk: true, wantGenres: 1},
+		{name: "only co-occurrence", profile: Profile{CoOccurrence: map[string]float64{"tt0111161": 1}}, wantOk: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, ok := personalFilter(tt.profile)
+			if ok != tt.wantOk {
+				t.Fatalf("personalFilter ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+
+			// every $in must be non-empty array, mongodb rejects null
+			for _, branch := range filter["$or"].(bson.A) {
+				for field, condition := range branch.(bson.M) {
+					values, isArray := condition.(bson.M)["$in"].([]string)
+					if !isArray || len(values) == 0 {
+						t.Errorf("%s $in = %#v, want non-empty list", field, condition.(bson.M)["$in"])
+					}
+					if field == "genre.genre_name" && len(values) != tt.wantGenres {
+						t.Errorf("liked genres = %v, want %d", values, tt.wantGenres)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines affinity added to genres that user asked for, it outweighs usual taste of user
+const requestedGenreAffinity = 3.0
+
+// creates function that recommends movies matching query parsed from user prompt, ranking filter is dropped when no movie matches it,
+// returns query that was used in the end
+func RecommendForQuery(ctx context.Context, client *mongo.Client, profile Profile, query models.RecommendationQuery, opts Options) ([]Recommendation, models.RecommendationQuery, error) {
+	// without filters prompt gives no hints, so usual recommendations are returned
+	if query.IsEmpty() {
+		candidates, err := LoadCandidates(ctx, client, profile)
+		if err != nil {
+			return nil, query, err
+		}
+		return explainAll(Recommend(candidates, profile, opts), profile, query), query, nil
+	}
+
+	// loads movies matching query
+	candidates, err := loadQueryCandidates(ctx, client, profile, query)
+	if err != nil {
+		return nil, query, err
+	}
+	if len(candidates) == 0 && len(query.Genres) > 0 && len(query.Rankings) > 0 {
+		query.Rankings = nil
+		candidates, err = loadQueryCandidates(ctx, client, profile, query)
+		if err != nil {
+			return nil, query, err
+		}
+	}
+
+	// boosts requested genres in copy of profile, so that movies with more of them come first
+	boosted := profile
+	boosted.GenreAffinity = make(map[string]float64, len(profile.GenreAffinity)+len(query.Genres))
+	for genre, affinity := range profile.GenreAffinity {
+		boosted.GenreAffinity[genre] = affinity
+	}
+	for _, genre := range query.Genres {
+		boosted.GenreAffinity[genre] += requestedGenreAffinity
+	}
+
+	return explainAll(Recommend(candidates, boosted, opts), profile, query), query, nil
+}
+
+// creates function that loads unseen movies that have one of requested genres and one of requested rankings
+func loadQueryCandidates(ctx context.Context, client *mongo.Client, profile Profile, query models.RecommendationQuery) ([]models.Movie, error) {
+	filter := bson.M{"imdb_id": bson.M{"$nin": keys(profile.Seen)}}
+	if len(query.Genres) > 0 {
+		filter["genre.genre_name"] = bson.M{"$in": query.Genres}
+	}
+	if len(query.Rankings) > 0 {
+		filter["ranking.ranking_name"] = bson.M{"$in": query.Rankings}
+	}
+
+	var candidates []models.Movie
+	err := findAll(ctx, database.OpenCollection("movies", client), filter, &candidates, options.Find().SetLimit(maxCandidates))
+	return candidates, err
+}
+
+// creates function that adds explanation to every recommendation
+func explainAll(recommendations []Recommendation, profile Profile, query models.RecommendationQuery) []Recommendation {
+	for i := range recommendations {
+		recommendations[i].Explanation = Explain(recommendations[i].Movie, profile, query)
+	}
+	return recommendations
+}
+
+// creates function that builds short explanation of why movie was recommended, e.g. "Comedy you asked for, ranked Excellent by our critics"
+func Explain(movie models.Movie, profile Profile, query models.RecommendationQuery) string {
+	var reasons []string
+
+	// tells which requested genres movie has and which of its genres user likes
+	var requested, liked []string
+	for _, genre := range movie.Genre {
+		switch {
+		case slices.Contains(query.Genres, genre.GenreName):
+			requested = append(requested, genre.GenreName)
+		case profile.GenreAffinity[genre.GenreName] > 0:
+			liked = append(liked, genre.GenreName)
+		}
+	}
+	if len(requested) > 0 {
+		reasons = append(reasons, strings.Join(requested, " and ")+" you asked for")
+	}
+
+	// tells admin ranking of ranked movies
+	if value := movie.Ranking.RankingValue; value > 0 && value != notRankedValue {
+		reasons = append(reasons, "ranked "+movie.Ranking.RankingName+" by our critics")
+	}
+
+	// tells audience score
+	if movie.AudienceScore.Count > 0 {
+		reasons = append(reasons, fmt.Sprintf("rated %.1f/10 by %d viewers", movie.AudienceScore.Mean, movie.AudienceScore.Count))
+	}
+
+	// tells personal signals
+	if profile.CoOccurrence[movie.ImdbID] > 0 {
+		reasons = append(reasons, "liked by viewers with similar taste")
+	} else if len(liked) > 0 {
+		reasons = append(reasons, "you enjoy "+strings.Join(liked, " and "))
+	}
+
+	if len(reasons) == 0 {
+		return "Popular pick from our catalog"
+	}
+	explanation := strings.Join(reasons, ", ")
+	return strings.ToUpper(explanation[:1]) + explanation[1:]
+}
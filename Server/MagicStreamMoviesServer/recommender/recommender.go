@@ -0,0 +1,180 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"math"
+	"sort"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// defines value of admin ranking that is used for movies which are not ranked
+const notRankedValue = 999
+
+// defines Weights struct that sets how much every signal adds to movie score
+type Weights struct {
+	GenreAffinity float64
+	CoOccurrence  float64
+	Popularity    float64
+	Quality       float64
+}
+
+// defines default weights, genre affinity and co-occurrence are personal signals and weigh more than popularity and quality
+var DefaultWeights = Weights{
+	GenreAffinity: 0.4,
+	CoOccurrence:  0.3,
+	Popularity:    0.15,
+	Quality:       0.15,
+}
+
+// defines Profile struct that holds signals about user taste
+type Profile struct {
+	// GenreAffinity maps genre name to how much user likes it, negative values mean user dislikes genre
+	GenreAffinity map[string]float64
+	// CoOccurrence maps movie id to how often users with similar taste liked it, values are in [0, 1] range
+	CoOccurrence map[string]float64
+	// Seen holds ids of movies that user has watched or rated, they are never recommended
+	Seen map[string]bool
+}
+
+// defines Options struct that sets size and diversity of recommendations
+type Options struct {
+	Limit int
+	// MaxPerGenre caps how many recommended movies can share one genre, zero means no cap
+	MaxPerGenre int
+	Weights     Weights
+}
+
+// defines Recommendation struct that holds recommended movie with its score
+type Recommendation struct {
+	Movie models.Movie `json:"movie"`
+	Score float64      `json:"score"`
+	// Explanation tells user in few words why movie was picked
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// creates function that scores candidate movies for user and picks best of them keeping genres diverse
+func Recommend(candidates []models.Movie, profile Profile, opts Options) []Recommendation {
+	// defines highest rating count used to scale popularity
+	maxCount := int64(0)
+	for _, movie := range candidates {
+		maxCount = max(maxCount, movie.AudienceScore.Count)
+	}
+
+	// scores movies that user has not seen yet
+	scored := make([]Recommendation, 0, len(candidates))
+	for _, movie := range candidates {
+		if profile.Seen[movie.ImdbID] {
+			continue
+		}
+		score := opts.Weights.GenreAffinity*genreAffinity(movie, profile.GenreAffinity) +
+			opts.Weights.CoOccurrence*profile.CoOccurrence[movie.ImdbID] +
+			opts.Weights.Popularity*popularity(movie, maxCount) +
+			opts.Weights.Quality*quality(movie)
+		scored = append(scored, Recommendation{Movie: movie, Score: score})
+	}
+
+	// orders movies by score, movies with equal score are ordered by title to keep results stable
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Movie.Title < scored[j].Movie.Title
+	})
+
+	return diversify(scored, opts.Limit, opts.MaxPerGenre)
+}
+
+// creates function that picks best movies so that no genre is used more than maxPerGenre times, fills free places with best skipped movies if needed
+func diversify(scored []Recommendation, limit, maxPerGenre int) []Recommendation {
+	if limit <= 0 || limit > len(scored) {
+		limit = len(scored)
+	}
+
+	picked := make([]Recommendation, 0, limit)
+	skipped := []Recommendation{}
+	genreCounts := map[string]int{}
+
+	for _, recommendation := range scored {
+		if len(picked) == limit {
+			break
+		}
+		// skips movie if one of its genres is already used too often
+		if maxPerGenre > 0 && exceedsGenreCap(recommendation.Movie, genreCounts, maxPerGenre) {
+			skipped = append(skipped, recommendation)
+			continue
+		}
+		picked = append(picked, recommendation)
+		for _, genre := range recommendation.Movie.Genre {
+			genreCounts[genre.GenreName]++
+		}
+	}
+
+	// relaxes cap when there are not enough diverse movies
+	for _, recommendation := range skipped {
+		if len(picked) == limit {
+			break
+		}
+		picked = append(picked, recommendation)
+	}
+
+	return picked
+}
+
+// creates function that checks if one of movie genres has already been picked maxPerGenre times
+func exceedsGenreCap(movie models.Movie, genreCounts map[string]int, maxPerGenre int) bool {
+	for _, genre := range movie.Genre {
+		if genreCounts[genre.GenreName] >= maxPerGenre {
+			return true
+		}
+	}
+	return false
+}
+
+// creates function that counts how much user likes genres of movie, result is in [-1, 1] range
+func genreAffinity(movie models.Movie, affinity map[string]float64) float64 {
+	if len(movie.Genre) == 0 || len(affinity) == 0 {
+		return 0
+	}
+
+	// defines strongest affinity used to scale result
+	strongest := 0.0
+	for _, value := range affinity {
+		strongest = max(strongest, math.Abs(value))
+	}
+	if strongest == 0 {
+		return 0
+	}
+
+	// averages affinity of movie genres
+	total := 0.0
+	for _, genre := range movie.Genre {
+		total += affinity[genre.GenreName]
+	}
+	return total / float64(len(movie.Genre)) / strongest
+}
+
+// creates function that scales number of audience ratings to [0, 1] range, log scale keeps blockbusters from taking over
+func popularity(movie models.Movie, maxCount int64) float64 {
+	if maxCount == 0 {
+		return 0
+	}
+	return math.Log1p(float64(movie.AudienceScore.Count)) / math.Log1p(float64(maxCount))
+}
+
+// creates function that scales audience score and admin ranking to [0, 1] range
+func quality(movie models.Movie) float64 {
+	// admin ranking 1 is best, unranked movies are neutral
+	adminQuality := 0.5
+	if value := movie.Ranking.RankingValue; value > 0 && value != notRankedValue {
+		adminQuality = math.Max(0, 1-float64(value-1)/4)
+	}
+	if movie.AudienceScore.Count == 0 {
+		return adminQuality
+	}
+
+	// audience weighted score is in [1, 10] range
+	audienceQuality := (movie.AudienceScore.WeightedScore - 1) / 9
+	return (adminQuality + audienceQuality) / 2
+}
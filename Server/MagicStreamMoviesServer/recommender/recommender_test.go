@@ -0,0 +1,127 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"math"
+	"testing"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// creates function that builds movie with given genres, admin ranking and audience score
+func testMovie(id string, ranking int, count int64, weightedScore float64, genres ...string) models.Movie {
+	movie := models.Movie{
+		ImdbID:        id,
+		Title:         id,
+		Ranking:       models.Ranking{RankingValue: ranking},
+		AudienceScore: models.AudienceScore{Count: count, WeightedScore: weightedScore},
+	}
+	for _, genre := range genres {
+		movie.Genre = append(movie.Genre, models.Genre{GenreName: genre})
+	}
+	return movie
+}
+
+func TestRecommendScoresWithDefaultWeights(t *testing.T) {
+	candidates := []models.Movie{
+		testMovie("drama", 1, 100, 8.2, "Drama"),
+		testMovie("horror", notRankedValue, 0, 5.5, "Horror"),
+		testMovie("mixed", 3, 10, 5.5, "Drama", "Horror"),
+	}
+	profile := Profile{
+		GenreAffinity: map[string]float64{"Drama": 2, "Horror": -1},
+		CoOccurrence:  map[string]float64{"drama": 0.5, "mixed": 1},
+	}
+
+	// expected scores are sums of weighted signals: genre affinity, co-occurrence, popularity and quality
+	want := map[string]float64{
+		// affinity 2/2, popularity log1p(100)/log1p(100), quality (admin 1 + audience (8.2-1)/9) / 2
+		"drama": 0.4*1 + 0.3*0.5 + 0.15*1 + 0.15*(1+0.8)/2,
+		// affinity -1/2, no ratings so quality is neutral admin quality
+		"horror": 0.4*-0.5 + 0.15*0.5,
+		// affinity (2-1)/2/2, quality (admin 0.5 + audience 0.5) / 2
+		"mixed": 0.4*0.25 + 0.3*1 + 0.15*math.Log1p(10)/math.Log1p(100) + 0.15*(0.5+0.5)/2,
+	}
+	wantOrder := []string{"drama", "mixed", "horror"}
+
+	got := Recommend(candidates, profile, Options{Weights: DefaultWeights})
+	if len(got) != len(wantOrder) {
+		t.Fatalf("Recommend returned %d movies, want %d", len(got), len(wantOrder))
+	}
+	for i, recommendation := range got {
+		if recommendation.Movie.ImdbID != wantOrder[i] {
+			t.Errorf("recommendation %d = %s, want %s", i, recommendation.Movie.ImdbID, wantOrder[i])
+		}
+		if score := want[recommendation.Movie.ImdbID]; math.Abs(recommendation.Score-score) > 1e-9 {
+			t.Errorf("score of %s = %v, want %v", recommendation.Movie.ImdbID, recommendation.Score, score)
+		}
+	}
+}
+
+func TestRecommendExcludesSeenMovies(t *testing.T) {
+	candidates := []models.Movie{
+		testMovie("watched", 1, 50, 9, "Drama"),
+		testMovie("rated", 1, 50, 9, "Drama"),
+		testMovie("new", 4, 1, 4, "Comedy"),
+	}
+	profile := Profile{
+		GenreAffinity: map[string]float64{"Drama": 1},
+		Seen:          map[string]bool{"watched": true, "rated": true},
+	}
+
+	got := Recommend(candidates, profile, Options{Limit: 10, Weights: DefaultWeights})
+	if len(got) != 1 || got[0].Movie.ImdbID != "new" {
+		t.Errorf("Recommend = %v, want only unseen movie", ids(got))
+	}
+}
+
+func TestDiversify(t *testing.T) {
+	scored := []Recommendation{
+		{Movie: testMovie("drama1", 1, 0, 0, "Drama"), Score: 0.9},
+		{Movie: testMovie("drama2", 1, 0, 0, "Drama"), Score: 0.8},
+		{Movie: testMovie("drama3", 1, 0, 0, "Drama"), Score: 0.7},
+		{Movie: testMovie("dramedy", 1, 0, 0, "Drama", "Comedy"), Score: 0.6},
+		{Movie: testMovie("comedy", 1, 0, 0, "Comedy"), Score: 0.5},
+		{Movie: testMovie("horror", 1, 0, 0, "Horror"), Score: 0.4},
+	}
+
+	tests := []struct {
+		name        string
+		limit       int
+		maxPerGenre int
+		want        []string
+	}{
+		{name: "no cap keeps order", limit: 3, want: []string{"drama1", "drama2", "drama3"}},
+		{name: "cap skips movies of used genre", limit: 4, maxPerGenre: 2,
+			want: []string{"drama1", "drama2", "comedy", "horror"}},
+		{name: "cap counts every genre of movie", limit: 4, maxPerGenre: 1,
+			want: []string{"drama1", "comedy", "horror", "drama2"}},
+		{name: "skipped movies fill free places", limit: 6, maxPerGenre: 2,
+			want: []string{"drama1", "drama2", "comedy", "horror", "drama3", "dramedy"}},
+		{name: "zero limit returns all", limit: 0, maxPerGenre: 0,
+			want: []string{"drama1", "drama2", "drama3", "dramedy", "comedy", "horror"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(diversify(scored, tt.limit, tt.maxPerGenre))
+			if len(got) != len(tt.want) {
+				t.Fatalf("diversify = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("diversify = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// creates function that gets movie ids of recommendations
+func ids(recommendations []Recommendation) []string {
+	result := make([]string, len(recommendations))
+	for i, recommendation := range recommendations {
+		result[i] = recommendation.Movie.ImdbID
+	}
+	return result
+}
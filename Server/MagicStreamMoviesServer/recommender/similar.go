@@ -0,0 +1,204 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines how many texts are sent to embedder at once
+const embeddingBatchSize = 64
+
+// creates function that finds movies most similar to given movie by cosine similarity of their embeddings, it only reads stored vectors,
+// they are built in background by embedding sync, movie without embedding yet has no similar movies
+func SimilarMovies(ctx context.Context, client *mongo.Client, embedder llm.Embedder, imdbID string, limit int) ([]Recommendation, error) {
+	movieCollection := database.OpenCollection("movies", client)
+	embeddingCollection := database.OpenCollection("movie_embeddings", client)
+
+	// checks that movie exists
+	var movie models.Movie
+	if err := movieCollection.FindOne(ctx, bson.M{"imdb_id": imdbID}).Decode(&movie); err != nil {
+		return nil, err
+	}
+
+	// gets embedding of movie
+	var target models.MovieEmbedding
+	err := embeddingCollection.FindOne(ctx, bson.M{"imdb_id": imdbID, "provider": embedder.Name()}).Decode(&target)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return []Recommendation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// gets embeddings of other movies
+	var stored []models.MovieEmbedding
+	err = findAll(ctx, embeddingCollection, bson.M{"provider": embedder.Name(), "imdb_id": bson.M{"$ne": imdbID}}, &stored,
+		options.Find().SetProjection(bson.M{"imdb_id": 1, "vector": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	// finds nearest movies
+	neighbours := nearestNeighbours(target.Vector, stored, limit)
+
+	// loads nearest movies and keeps them in order of similarity
+	ids := make([]string, len(neighbours))
+	for i, n := range neighbours {
+		ids[i] = n.imdbID
+	}
+	var movies []models.Movie
+	if err := findAll(ctx, movieCollection, bson.M{"imdb_id": bson.M{"$in": ids}}, &movies); err != nil {
+		return nil, err
+	}
+	moviesById := make(map[string]models.Movie, len(movies))
+	for _, m := range movies {
+		moviesById[m.ImdbID] = m
+	}
+	similar := make([]Recommendation, 0, len(neighbours))
+	for _, n := range neighbours {
+		if m, ok := moviesById[n.imdbID]; ok {
+			similar = append(similar, Recommendation{Movie: m, Score: n.similarity})
+		}
+	}
+
+	return similar, nil
+}
+
+// defines neighbour struct that holds id of movie and its similarity to compared movie
+type neighbour struct {
+	imdbID     string
+	similarity float64
+}
+
+// creates function that compares vector with every stored embedding and gets the most similar ones first, catalog is small enough
+// for brute force search, zero limit returns all of them
+func nearestNeighbours(vector []float32, stored []models.MovieEmbedding, limit int) []neighbour {
+	neighbours := make([]neighbour, 0, len(stored))
+	for _, embedding := range stored {
+		neighbours = append(neighbours, neighbour{imdbID: embedding.ImdbID, similarity: cosineSimilarity(vector, embedding.Vector)})
+	}
+	sort.Slice(neighbours, func(i, j int) bool {
+		if neighbours[i].similarity != neighbours[j].similarity {
+			return neighbours[i].similarity > neighbours[j].similarity
+		}
+		return neighbours[i].imdbID < neighbours[j].imdbID
+	})
+	if limit > 0 && len(neighbours) > limit {
+		neighbours = neighbours[:limit]
+	}
+	return neighbours
+}
+
+// creates function that builds missing embeddings of movies and rebuilds ones whose movie text or embedder has changed,
+// it reads whole catalog and may call remote embedder, so it runs in background and never on request path
+func SyncEmbeddings(ctx context.Context, client *mongo.Client, embedder llm.Embedder) error {
+	embeddingCollection := database.OpenCollection("movie_embeddings", client)
+
+	// gets embedded fields of all movies
+	var movies []models.Movie
+	err := findAll(ctx, database.OpenCollection("movies", client), bson.M{}, &movies,
+		options.Find().SetProjection(bson.M{"imdb_id": 1, "title": 1, "genre": 1, "admin_review": 1}))
+	if err != nil {
+		return err
+	}
+
+	// gets text hashes of stored embeddings of current embedder, vectors are not needed to find stale ones
+	var stored []models.MovieEmbedding
+	err = findAll(ctx, embeddingCollection, bson.M{"provider": embedder.Name()}, &stored,
+		options.Find().SetProjection(bson.M{"imdb_id": 1, "text_hash": 1}))
+	if err != nil {
+		return err
+	}
+	hashById := make(map[string]string, len(stored))
+	for _, embedding := range stored {
+		hashById[embedding.ImdbID] = embedding.TextHash
+	}
+
+	// collects movies that have no embedding or whose text has changed
+	var stale []models.MovieEmbedding
+	var staleTexts []string
+	for _, movie := range movies {
+		text := MovieText(movie)
+		hash := textHash(text)
+		if storedHash, ok := hashById[movie.ImdbID]; ok && storedHash == hash {
+			continue
+		}
+		stale = append(stale, models.MovieEmbedding{ImdbID: movie.ImdbID, Provider: embedder.Name(), TextHash: hash})
+		staleTexts = append(staleTexts, text)
+	}
+
+	// embeds stale movies in batches and stores their vectors
+	for start := 0; start < len(stale); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(stale))
+		embedded, err := embedder.Embed(ctx, staleTexts[start:end])
+		if err != nil {
+			return err
+		}
+
+		writes := make([]mongo.WriteModel, 0, end-start)
+		for i, vector := range embedded {
+			embedding := stale[start+i]
+			embedding.Vector = vector
+			embedding.UpdatedAt = time.Now()
+			writes = append(writes, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"imdb_id": embedding.ImdbID}).
+				SetReplacement(embedding).
+				SetUpsert(true))
+		}
+		// another server instance may have stored the same embedding first, its vector is just as good
+		if _, err := embeddingCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// creates function that builds text of movie that is embedded, genre names are listed twice so that they weigh as much as long admin review,
+// text has no labels because words shared by every movie would make unrelated movies look similar
+func MovieText(movie models.Movie) string {
+	genres := make([]string, len(movie.Genre))
+	for i, genre := range movie.Genre {
+		genres[i] = genre.GenreName
+	}
+	genreText := strings.Join(genres, ", ")
+	return movie.Title + "\n" + genreText + "\n" + genreText + "\n" + movie.AdminReview
+}
+
+// creates function that hashes embedded text
+func textHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// creates function that counts cosine similarity of two vectors, result is in [-1, 1] range
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
@@ -0,0 +1,101 @@
+// marks file as part of recommender package
+package recommender
+
+// imports packages
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// creates function that embeds movies with hashing embedder the same way embedding sync does
+func embedMovies(t *testing.T, movies ...models.Movie) []models.MovieEmbedding {
+	t.Helper()
+	texts := make([]string, len(movies))
+	for i, movie := range movies {
+		texts[i] = MovieText(movie)
+	}
+	vectors, err := llm.NewHashingEmbedder(512).Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed returned error: %v", err)
+	}
+	embeddings := make([]models.MovieEmbedding, len(movies))
+	for i, movie := range movies {
+		embeddings[i] = models.MovieEmbedding{ImdbID: movie.ImdbID, Vector: vectors[i]}
+	}
+	return embeddings
+}
+
+// creates function that builds movie with title, admin review and genres
+func reviewedMovie(id, title, review string, genres ...string) models.Movie {
+	movie := testMovie(id, 1, 0, 0, genres...)
+	movie.Title = title
+	movie.AdminReview = review
+	return movie
+}
+
+func TestMovieText(t *testing.T) {
+	text := MovieText(reviewedMovie("tt0133093", "The Matrix", "Mind bending action.", "Sci-Fi", "Action"))
+
+	if strings.Contains(strings.ToLower(text), "genres") {
+		t.Errorf("MovieText = %q, want no label shared by every movie", text)
+	}
+	if count := strings.Count(text, "Sci-Fi, Action"); count != 2 {
+		t.Errorf("MovieText lists genres %d times, want 2", count)
+	}
+	if !strings.HasPrefix(text, "The Matrix\n") || !strings.HasSuffix(text, "Mind bending action.") {
+		t.Errorf("MovieText = %q, want title first and admin review last", text)
+	}
+}
+
+func TestNearestNeighbours(t *testing.T) {
+	embeddings := embedMovies(t,
+		reviewedMovie("matrix", "The Matrix", "A hacker fights machines that rule a simulated world.", "Sci-Fi", "Action"),
+		reviewedMovie("terminator", "The Terminator", "A cyborg assassin is sent back in time.", "Sci-Fi", "Action"),
+		reviewedMovie("notting-hill", "Notting Hill", "A bookshop owner falls for a film star.", "Romance", "Comedy"),
+		reviewedMovie("matrix-copy", "The Matrix", "A hacker fights machines that rule a simulated world.", "Sci-Fi", "Action"),
+	)
+	target, others := embeddings[0], embeddings[1:]
+
+	neighbours := nearestNeighbours(target.Vector, others, 0)
+	want := []string{"matrix-copy", "terminator", "notting-hill"}
+	if len(neighbours) != len(want) {
+		t.Fatalf("nearestNeighbours returned %d movies, want %d", len(neighbours), len(want))
+	}
+	for i, n := range neighbours {
+		if n.imdbID != want[i] {
+			t.Errorf("neighbour %d = %s (%v), want %s", i, n.imdbID, n.similarity, want[i])
+		}
+	}
+	if math.Abs(neighbours[0].similarity-1) > 1e-5 {
+		t.Errorf("similarity of movie with identical text = %v, want 1", neighbours[0].similarity)
+	}
+
+	// limit keeps the nearest movies
+	if limited := nearestNeighbours(target.Vector, others, 2); len(limited) != 2 || limited[1].imdbID != "terminator" {
+		t.Errorf("nearestNeighbours with limit 2 = %+v, want matrix-copy and terminator", limited)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{name: "same direction", a: []float32{1, 2}, b: []float32{2, 4}, want: 1},
+		{name: "opposite direction", a: []float32{1, 0}, b: []float32{-3, 0}, want: -1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 5}, want: 0},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 1}, want: 0},
+		{name: "different dimensions", a: []float32{1, 0}, b: []float32{1, 0, 0}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: cosineSimilarity = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,24 +3,87 @@ package routes
 
 // imports packages
 import (
+	"os"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	controller "github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/controllers"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/jobs"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/llm"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/mailer"
 	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/middleware"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 // creates function that sets up protected routes for authenticated users
-func SetupProtectedRoutes(router *gin.Engine, client *mongo.Client) {
+func SetupProtectedRoutes(router *gin.Engine, client *mongo.Client, queue *jobs.ReviewQueue, embeddings *jobs.EmbeddingSync, embedder llm.Embedder, parser llm.PromptParser, mail mailer.Mailer, guard *loginguard.Guard) {
+
+	// creates route group for account routes, AuthMiddleware requires user to be logged in, email does not have to be verified
+	accountRoutes := router.Group("", middleware.AuthMiddleWare(client))
 
-	// uses AuthMiddleware function to protect routes, requires user to be logged in
-	router.Use(middleware.AuthMiddleWare())
+	// creates route for me endpoint that handles GET requests to read profile of logged in user
+	accountRoutes.GET("/me", controller.GetProfile(client))
+	// creates route for verify email resend endpoint that handles POST requests to send new verification email
+	accountRoutes.POST("/verify-email/resend", controller.ResendVerificationEmail(client, mail))
+
+	// creates route group for protected routes, when REQUIRE_EMAIL_VERIFICATION env is true user must also have verified email
+	var verification []gin.HandlerFunc
+	if required, _ := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION")); required {
+		verification = append(verification, middleware.RequireVerifiedEmail(client))
+	}
+	protectedRoutes := accountRoutes.Group("", verification...)
 
 	// creates route for movie endpoint that handles GET requests to get certain movie from database
-	router.GET("/movie/:movie_id", controller.GetMovie(client))
-	// creates route for add-movie endpoint that handles POST requests to add new movie to database
-	router.POST("/add-movie", controller.AddMovie(client))
+	protectedRoutes.GET("/movie/:imdb_id", controller.GetMovie(client))
+	// creates route for similar movies endpoint that handles GET requests to get movies most like certain movie
+	protectedRoutes.GET("/movie/:imdb_id/similar", controller.GetSimilarMovies(client, embedder))
 	// creates route for recommended-movies endpoint that handles GET requests to get recommended movies
-	router.GET("/recommendedmovies", controller.GetRecommendedMovies(client))
+	protectedRoutes.GET("/recommendedmovies", controller.GetRecommendedMovies(client))
+	// creates route for recommended-movies endpoint that handles POST requests to get movies recommended for free text request of user
+	protectedRoutes.POST("/recommendedmovies", controller.RecommendMoviesFromPrompt(client, parser))
+	// creates route for movie rating endpoint that handles PUT requests to submit user star rating of movie
+	protectedRoutes.PUT("/movie/:imdb_id/rating", controller.RateMovie(client))
+	// creates routes for movie reviews endpoint that handle GET and POST requests to list and write user reviews
+	protectedRoutes.GET("/movie/:imdb_id/reviews", controller.GetMovieReviews(client))
+	protectedRoutes.POST("/movie/:imdb_id/reviews", controller.CreateReview(client, queue))
+	// creates routes for reviews endpoint that handle PATCH and DELETE requests to edit or delete own review
+	protectedRoutes.PATCH("/reviews/:review_id", controller.UpdateReview(client, queue))
+	protectedRoutes.DELETE("/reviews/:review_id", controller.DeleteReview(client))
+	// creates route for movie progress endpoint that handles POST requests to save playback position of user
+	protectedRoutes.POST("/movie/:imdb_id/progress", controller.SaveProgress(client))
+	// creates route for history endpoint that handles GET requests to get recently watched movies with resume points
+	protectedRoutes.GET("/history", controller.GetHistory(client))
+	// creates route for me endpoint that handles PATCH requests to edit profile of logged in user
+	protectedRoutes.PATCH("/me", controller.UpdateProfile(client))
+	// creates route for me password endpoint that handles POST requests to change password of logged in user
+	protectedRoutes.POST("/me/password", controller.ChangePassword(client))
+	// creates routes for me sessions endpoint that handle GET and DELETE requests to list devices of user and log them out
+	protectedRoutes.GET("/me/sessions", controller.GetSessions(client))
+	protectedRoutes.DELETE("/me/sessions", controller.RevokeOtherSessions(client))
+	protectedRoutes.DELETE("/me/sessions/:session_id", controller.RevokeSession(client))
+	// creates routes for watchlist endpoint that handle GET, POST and DELETE requests to manage movies saved by user
+	protectedRoutes.GET("/watchlist", controller.GetWatchlist(client))
+	protectedRoutes.POST("/watchlist/:imdb_id", controller.AddToWatchlist(client))
+	protectedRoutes.DELETE("/watchlist/:imdb_id", controller.RemoveFromWatchlist(client))
+	// creates routes for review-jobs endpoint that handle GET requests to poll or wait for review ranking
+	protectedRoutes.GET("/review-jobs/:job_id", controller.GetReviewJob(queue))
+	protectedRoutes.GET("/review-jobs/:job_id/events", controller.ReviewJobEvents(queue))
+
+	// creates route group for admin routes, requires user to be logged in and to have ADMIN role
+	adminRoutes := protectedRoutes.Group("", middleware.RequireRole("ADMIN"))
+
+	// creates route for add-movie endpoint that handles POST requests to add new movie to database
+	// Idempotency-Key header makes retries of the same request safe
+	adminRoutes.POST("/add-movie", middleware.IdempotencyMiddleware(client), controller.AddMovie(client, embeddings))
+	// creates routes for movie endpoint that handle PUT, PATCH and DELETE requests to update or remove movie by imdb id
+	adminRoutes.PUT("/movie/:imdb_id", controller.UpdateMovie(client, embeddings))
+	adminRoutes.PATCH("/movie/:imdb_id", controller.PatchMovie(client, embeddings))
+	adminRoutes.DELETE("/movie/:imdb_id", controller.DeleteMovie(client))
 	// creates route for update review endpoint that handles Patch requests to update movie review by imdb id
-	router.PATCH("/updatereview/:imdb_id", controller.AdminReviewUpdate(client))
+	adminRoutes.PATCH("/updatereview/:imdb_id", controller.AdminReviewUpdate(client, queue, embeddings))
+	// creates route for review visibility endpoint that handles PATCH requests to hide or unhide user review
+	adminRoutes.PATCH("/reviews/:review_id/visibility", controller.SetReviewVisibility(client))
+	// creates route for login unlock endpoint that handles POST requests to unlock account or client ip locked after failed logins
+	adminRoutes.POST("/admin/login-unlock", controller.UnlockLogin(guard))
 }
@@ -0,0 +1,119 @@
+// marks file as part of routes package
+package routes
+
+// imports packages
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines routes that must be reachable without token, every other route must require it
+var publicRoutes = map[string]bool{
+	"GET /movies":                true,
+	"GET /movies/search":         true,
+	"GET /genres":                true,
+	"GET /.well-known/jwks.json": true,
+	"GET /verify-email":          true,
+	"POST /register":             true,
+	"POST /login":                true,
+	"POST /logout":               true,
+	"POST /refresh":              true,
+	"POST /password/forgot":      true,
+	"POST /password/reset":       true,
+}
+
+// creates function that builds engine with all routes, database is unreachable so that handlers which reach it fail fast
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	client, err := mongo.Connect(options.Client().ApplyURI("mongodb://127.0.0.1:1").SetServerSelectionTimeout(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("mongo.Connect: %v", err)
+	}
+	guard := &loginguard.Guard{Store: loginguard.NewMemoryStore(), Policy: loginguard.Policy{
+		MaxAccountFailures: 5, MaxIPFailures: 50, BaseDelay: time.Second, MaxDelay: time.Minute,
+		LockoutDuration: time.Minute, Window: time.Minute,
+	}}
+
+	router := gin.New()
+	throttle := &loginguard.Throttle{Store: guard.Store, Name: "password_reset", MaxPerAccount: 3, MaxPerIP: 20, Window: time.Minute}
+	SetUpUnprotectedRoutes(router, client, nil, guard, throttle)
+	SetupProtectedRoutes(router, client, nil, nil, nil, nil, nil, guard)
+	return router
+}
+
+// creates function that sends request without token to route, path parameters are filled with placeholder
+func serveWithoutToken(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "test"
+		}
+	}
+	req := httptest.NewRequest(method, strings.Join(segments, "/"), strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRoutesRequireAuth(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		t.Run(key, func(t *testing.T) {
+			status := serveWithoutToken(router, route.Method, route.Path).Code
+			if publicRoutes[key] {
+				if status == http.StatusUnauthorized {
+					t.Errorf("public route %s returned 401 without token", key)
+				}
+				return
+			}
+			if status != http.StatusUnauthorized {
+				t.Errorf("protected route %s returned %d without token, want 401", key, status)
+			}
+		})
+	}
+}
+
+func TestPublicRoutesAreRegistered(t *testing.T) {
+	router := newTestRouter(t)
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for key := range publicRoutes {
+		if !registered[key] {
+			t.Errorf("public route %s is not registered", key)
+		}
+	}
+}
+
+func TestAdminRoutesRequireAuth(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/add-movie"},
+		{http.MethodPut, "/movie/:imdb_id"},
+		{http.MethodPatch, "/movie/:imdb_id"},
+		{http.MethodDelete, "/movie/:imdb_id"},
+		{http.MethodPatch, "/updatereview/:imdb_id"},
+		{http.MethodPatch, "/reviews/:review_id/visibility"},
+		{http.MethodPost, "/admin/login-unlock"},
+	} {
+		if status := serveWithoutToken(router, route.method, route.path).Code; status != http.StatusUnauthorized {
+			t.Errorf("admin route %s %s returned %d without token, want 401", route.method, route.path, status)
+		}
+	}
+}
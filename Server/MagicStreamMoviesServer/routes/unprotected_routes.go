@@ -5,22 +5,34 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/controllers"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/loginguard"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/mailer"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 // creates function that sets up unprotected routes for unauthenticated users
-func SetUpUnprotectedRoutes(router *gin.Engine, client *mongo.Client) {
+func SetUpUnprotectedRoutes(router *gin.Engine, client *mongo.Client, mail mailer.Mailer, guard *loginguard.Guard, resetThrottle *loginguard.Throttle) {
 	// creates route for movies endpoint that handles GET requests to get all movies from database
 	router.GET("/movies", controller.GetMovies(client))
+	// creates route for movies search endpoint that handles GET requests to search movies by title and admin review
+	router.GET("/movies/search", controller.SearchMovies(client))
 	// creates route for register endpoint that handles POST requests to add new user to database
-	router.POST("/register", controller.RegisterUser(client))
-	// creates route for login endpoint that handles POST requests to login user
-	router.POST("/login", controller.LoginUser(client))
+	router.POST("/register", controller.RegisterUser(client, mail))
+	// creates route for login endpoint that handles POST requests to login user, repeated failures are slowed down and locked
+	router.POST("/login", controller.LoginUser(client, guard))
 	// creates route for logout endpoint that handles POST requests to logout user
 	router.POST("/logout", controller.LogoutHandler(client))
 	// creates route for genres endpoint that handles GET requests to get all genres
 	router.GET("/genres", controller.GetGenres(client))
 	// creates route for refresh endpoint that handles POST requests to refresh token
 	router.POST("/refresh", controller.RefreshTokenHandler(client))
+	// creates route for jwks endpoint that handles GET requests to get public keys that verify tokens
+	router.GET("/.well-known/jwks.json", controller.GetJWKS())
+	// creates route for verify email endpoint that handles GET requests from link in verification email
+	router.GET("/verify-email", controller.VerifyEmail(client))
+	// creates route for password forgot endpoint that handles POST requests to email password reset link, requests are limited per email and ip
+	router.POST("/password/forgot", controller.ForgotPassword(client, mail, resetThrottle))
+	// creates route for password reset endpoint that handles POST requests to set new password with token from reset email
+	router.POST("/password/reset", controller.ResetPassword(client))
 
 }
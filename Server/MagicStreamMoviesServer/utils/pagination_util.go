@@ -0,0 +1,99 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+)
+
+// defines default and maximum page sizes
+const (
+	DefaultPageLimit int64 = 20
+	MaxPageLimit     int64 = 100
+)
+
+// defines Pagination struct that holds requested page and page size
+type Pagination struct {
+	Page  int64
+	Limit int64
+}
+
+// creates function that reads page and limit query parameters from context
+func GetPagination(c *gin.Context) (Pagination, error) {
+	// defines pagination with default values
+	pagination := Pagination{Page: 1, Limit: DefaultPageLimit}
+
+	// parses page query parameter if it is set
+	if pageStr := c.Query("page"); pageStr != "" {
+		page, err := strconv.ParseInt(pageStr, 10, 64)
+		if err != nil || page < 1 {
+			return pagination, errors.New("page must be a positive integer")
+		}
+		pagination.Page = page
+	}
+
+	// parses limit query parameter if it is set
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit < 1 {
+			return pagination, errors.New("limit must be a positive integer")
+		}
+		// caps limit to maximum page size
+		if limit > MaxPageLimit {
+			limit = MaxPageLimit
+		}
+		pagination.Limit = limit
+	}
+
+	// rejects page whose offset does not fit into int64, it would overflow to negative skip
+	if pagination.Page-1 > math.MaxInt64/pagination.Limit {
+		return pagination, errors.New("page is too large")
+	}
+
+	return pagination, nil
+}
+
+// creates method that returns number of documents to skip for current page
+func (p Pagination) Skip() int64 {
+	return (p.Page - 1) * p.Limit
+}
+
+// creates function that builds page metadata with total counts and links to next and previous pages
+func BuildPageMetadata(c *gin.Context, pagination Pagination, totalCount int64) models.PageMetadata {
+	// counts total pages
+	totalPages := (totalCount + pagination.Limit - 1) / pagination.Limit
+
+	// defines page metadata
+	metadata := models.PageMetadata{
+		Page:       pagination.Page,
+		Limit:      pagination.Limit,
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+	}
+
+	// sets link to next page if it exists
+	if pagination.Page < totalPages {
+		metadata.Next = buildPageLink(c, pagination.Page+1, pagination.Limit)
+	}
+	// sets link to previous page if it exists
+	if pagination.Page > 1 && pagination.Page <= totalPages {
+		metadata.Prev = buildPageLink(c, pagination.Page-1, pagination.Limit)
+	}
+
+	return metadata
+}
+
+// creates function that builds link to certain page keeping all other query parameters
+func buildPageLink(c *gin.Context, page, limit int64) string {
+	// copies query parameters from request url
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.FormatInt(page, 10))
+	query.Set("limit", strconv.FormatInt(limit, 10))
+
+	return c.Request.URL.Path + "?" + query.Encode()
+}
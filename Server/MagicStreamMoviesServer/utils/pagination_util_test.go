@@ -0,0 +1,56 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"math"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		query    string
+		want     Pagination
+		wantSkip int64
+		wantErr  bool
+	}{
+		{query: "", want: Pagination{Page: 1, Limit: DefaultPageLimit}, wantSkip: 0},
+		{query: "page=3&limit=10", want: Pagination{Page: 3, Limit: 10}, wantSkip: 20},
+		{query: "limit=1000", want: Pagination{Page: 1, Limit: MaxPageLimit}, wantSkip: 0},
+		{query: "page=0", wantErr: true},
+		{query: "page=abc", wantErr: true},
+		{query: "limit=-5", wantErr: true},
+		{query: "page=" + strconv.FormatInt(math.MaxInt64, 10), wantErr: true},
+		{query: "page=" + strconv.FormatInt(math.MaxInt64/MaxPageLimit+2, 10) + "&limit=100", wantErr: true},
+		{query: "page=" + strconv.FormatInt(math.MaxInt64/MaxPageLimit+1, 10) + "&limit=100",
+			want: Pagination{Page: math.MaxInt64/MaxPageLimit + 1, Limit: MaxPageLimit}, wantSkip: math.MaxInt64 / MaxPageLimit * MaxPageLimit},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/movies?"+tt.query, nil)
+
+		got, err := GetPagination(c)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("GetPagination(%q) = %+v, want error", tt.query, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GetPagination(%q) returned error: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want || got.Skip() != tt.wantSkip {
+			t.Errorf("GetPagination(%q) = %+v skip %d, want %+v skip %d", tt.query, got, got.Skip(), tt.want, tt.wantSkip)
+		}
+		if got.Skip() < 0 {
+			t.Errorf("GetPagination(%q) skip is negative", tt.query)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"os"
+	"strconv"
+)
+
+// defines default bayesian prior, movie with few ratings is pulled towards prior mean as if it had prior weight ratings of that value
+const (
+	defaultRatingPriorMean   = 5.5
+	defaultRatingPriorWeight = 10.0
+)
+
+// creates function that gets bayesian prior from RATING_PRIOR_MEAN and RATING_PRIOR_WEIGHT env
+func RatingPrior() (float64, float64) {
+	priorMean, priorWeight := defaultRatingPriorMean, defaultRatingPriorWeight
+	if value, err := strconv.ParseFloat(os.Getenv("RATING_PRIOR_MEAN"), 64); err == nil && value >= 1 && value <= 10 {
+		priorMean = value
+	}
+	if value, err := strconv.ParseFloat(os.Getenv("RATING_PRIOR_WEIGHT"), 64); err == nil && value >= 0 {
+		priorWeight = value
+	}
+	return priorMean, priorWeight
+}
@@ -0,0 +1,26 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import "testing"
+
+func TestRatingPrior(t *testing.T) {
+	tests := []struct {
+		mean, weight         string
+		wantMean, wantWeight float64
+	}{
+		{mean: "", weight: "", wantMean: defaultRatingPriorMean, wantWeight: defaultRatingPriorWeight},
+		{mean: "7", weight: "25", wantMean: 7, wantWeight: 25},
+		{mean: "6.5", weight: "0", wantMean: 6.5, wantWeight: 0},
+		// mean outside of star range and negative weight are ignored
+		{mean: "0", weight: "-1", wantMean: defaultRatingPriorMean, wantWeight: defaultRatingPriorWeight},
+		{mean: "11", weight: "abc", wantMean: defaultRatingPriorMean, wantWeight: defaultRatingPriorWeight},
+	}
+	for _, tt := range tests {
+		t.Setenv("RATING_PRIOR_MEAN", tt.mean)
+		t.Setenv("RATING_PRIOR_WEIGHT", tt.weight)
+		if mean, weight := RatingPrior(); mean != tt.wantMean || weight != tt.wantWeight {
+			t.Errorf("RatingPrior() with %q, %q = %v, %v, want %v, %v", tt.mean, tt.weight, mean, weight, tt.wantMean, tt.wantWeight)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines settings of revocation cache
+const (
+	// revocationCacheTTL is how long result of denylist lookup is trusted, revocations made by other server instances are seen after it
+	revocationCacheTTL = 30 * time.Second
+	// revocationCacheMaxSize is number of cached entries after which cache is pruned, stale entries are removed first
+	// and oldest ones after them until cache is down to three quarters of it, so that pruning runs rarely
+	revocationCacheMaxSize = 10000
+)
+
+// defines prefixes of denylist entries, token is revoked when its own id or its session is on denylist
+const (
+	revokedTokenPrefix   = "jti:"
+	revokedSessionPrefix = "session:"
+)
+
+// defines revokedEntry struct that is stored in revoked_tokens collection, entry is removed by ttl index once tokens it covers have expired
+type revokedEntry struct {
+	Key       string    `bson:"_id"`
+	RevokedAt time.Time `bson:"revoked_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// defines cachedRevocation struct that holds result of denylist lookup
+type cachedRevocation struct {
+	revoked   bool
+	checkedAt time.Time
+	expiresAt time.Time
+}
+
+// defines revocationCache that keeps recent denylist lookups in memory, so that most requests do not query database
+type revocationCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedRevocation
+}
+
+// defines cache used by IsTokenRevoked
+var revocations = &revocationCache{entries: map[string]cachedRevocation{}}
+
+// creates method that gets cached result of key, revoked keys stay cached until they expire and other keys for revocationCacheTTL
+func (r *revocationCache) get(key string, now time.Time) (bool, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[key]
+	if !ok {
+		return false, false
+	}
+	if entry.revoked && now.Before(entry.expiresAt) {
+		return true, true
+	}
+	if !entry.revoked && now.Sub(entry.checkedAt) < revocationCacheTTL {
+		return false, true
+	}
+	return false, false
+}
+
+// creates method that stores result of key and prunes cache when it grows, removed keys are just looked up in database again
+func (r *revocationCache) set(key string, entry cachedRevocation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[key] = entry
+	if len(r.entries) <= revocationCacheMaxSize {
+		return
+	}
+
+	// removes stale entries
+	now := entry.checkedAt
+	for k, e := range r.entries {
+		if (e.revoked && !now.Before(e.expiresAt)) || (!e.revoked && now.Sub(e.checkedAt) >= revocationCacheTTL) {
+			delete(r.entries, k)
+		}
+	}
+
+	// removes oldest entries when too many are still fresh
+	excess := len(r.entries) - revocationCacheMaxSize*3/4
+	if excess <= 0 {
+		return
+	}
+	type agedKey struct {
+		key       string
+		checkedAt time.Time
+	}
+	aged := make([]agedKey, 0, len(r.entries))
+	for k, e := range r.entries {
+		aged = append(aged, agedKey{key: k, checkedAt: e.checkedAt})
+	}
+	sort.Slice(aged, func(i, j int) bool { return aged[i].checkedAt.Before(aged[j].checkedAt) })
+	for _, a := range aged[:excess] {
+		delete(r.entries, a.key)
+	}
+}
+
+// creates function that checks if access token or its session has been revoked
+func IsTokenRevoked(ctx context.Context, client *mongo.Client, claims *SignedDetails) (bool, error) {
+	now := time.Now()
+
+	// defines denylist keys of token and collects ones that are not cached
+	var keys, missing []string
+	if claims.ID != "" {
+		keys = append(keys, revokedTokenPrefix+claims.ID)
+	}
+	if claims.SessionId != "" {
+		keys = append(keys, revokedSessionPrefix+claims.SessionId)
+	}
+	for _, key := range keys {
+		revoked, cached := revocations.get(key, now)
+		if revoked {
+			return true, nil
+		}
+		if !cached {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+
+	// looks up keys that are not cached
+	cursor, err := database.OpenCollection("revoked_tokens", client).Find(ctx, bson.M{"_id": bson.M{"$in": missing}})
+	if err != nil {
+		return false, err
+	}
+	var entries []revokedEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return false, err
+	}
+
+	// caches results
+	found := map[string]revokedEntry{}
+	for _, entry := range entries {
+		found[entry.Key] = entry
+	}
+	revoked := false
+	for _, key := range missing {
+		if entry, ok := found[key]; ok {
+			revocations.set(key, cachedRevocation{revoked: true, checkedAt: now, expiresAt: entry.ExpiresAt})
+			revoked = true
+		} else {
+			revocations.set(key, cachedRevocation{checkedAt: now})
+		}
+	}
+	return revoked, nil
+}
+
+// creates function that puts access token on denylist until it expires
+func RevokeToken(ctx context.Context, client *mongo.Client, claims *SignedDetails) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return addToDenylist(ctx, client, []string{revokedTokenPrefix + claims.ID}, claims.ExpiresAt.Time)
+}
+
+// creates function that puts sessions on denylist, access tokens of sessions are rejected until the longest of them expires
+func revokeSessionTokens(ctx context.Context, client *mongo.Client, sessionIds []string) error {
+	keys := make([]string, len(sessionIds))
+	for i, id := range sessionIds {
+		keys[i] = revokedSessionPrefix + id
+	}
+	return addToDenylist(ctx, client, keys, time.Now().Add(AccessTokenTTL))
+}
+
+// creates function that stores denylist entries and caches them right away, so that this server instance rejects tokens at once
+func addToDenylist(ctx context.Context, client *mongo.Client, keys []string, expiresAt time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	now := time.Now()
+	writes := make([]mongo.WriteModel, len(keys))
+	for i, key := range keys {
+		writes[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": key}).
+			SetReplacement(revokedEntry{Key: key, RevokedAt: now, ExpiresAt: expiresAt}).
+			SetUpsert(true)
+	}
+	if _, err := database.OpenCollection("revoked_tokens", client).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		revocations.set(key, cachedRevocation{revoked: true, checkedAt: now, expiresAt: expiresAt})
+	}
+	return nil
+}
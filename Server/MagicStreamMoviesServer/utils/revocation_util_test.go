@@ -0,0 +1,57 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRevocationCacheGet(t *testing.T) {
+	now := time.Now()
+	cache := &revocationCache{entries: map[string]cachedRevocation{}}
+	cache.set("jti:revoked", cachedRevocation{revoked: true, checkedAt: now, expiresAt: now.Add(time.Hour)})
+	cache.set("jti:expired", cachedRevocation{revoked: true, checkedAt: now, expiresAt: now.Add(-time.Second)})
+	cache.set("jti:fresh", cachedRevocation{checkedAt: now})
+	cache.set("jti:stale", cachedRevocation{checkedAt: now.Add(-revocationCacheTTL)})
+
+	tests := []struct {
+		key         string
+		wantRevoked bool
+		wantCached  bool
+	}{
+		{key: "jti:revoked", wantRevoked: true, wantCached: true},
+		{key: "jti:expired", wantRevoked: false, wantCached: false},
+		{key: "jti:fresh", wantRevoked: false, wantCached: true},
+		{key: "jti:stale", wantRevoked: false, wantCached: false},
+		{key: "jti:unknown", wantRevoked: false, wantCached: false},
+	}
+	for _, tt := range tests {
+		revoked, cached := cache.get(tt.key, now)
+		if revoked != tt.wantRevoked || cached != tt.wantCached {
+			t.Errorf("get(%q) = %v, %v, want %v, %v", tt.key, revoked, cached, tt.wantRevoked, tt.wantCached)
+		}
+	}
+}
+
+func TestRevocationCacheStaysBoundedWithFreshEntries(t *testing.T) {
+	now := time.Now()
+	cache := &revocationCache{entries: map[string]cachedRevocation{}}
+
+	// every entry is fresh, so only eviction of oldest ones keeps cache bounded
+	for i := 0; i < revocationCacheMaxSize*3; i++ {
+		cache.set("jti:"+strconv.Itoa(i), cachedRevocation{checkedAt: now.Add(time.Duration(i) * time.Microsecond)})
+		if len(cache.entries) > revocationCacheMaxSize {
+			t.Fatalf("cache has %d entries after %d inserts, want at most %d", len(cache.entries), i+1, revocationCacheMaxSize)
+		}
+	}
+
+	// newest entry is kept and oldest one is evicted
+	if _, ok := cache.entries["jti:"+strconv.Itoa(revocationCacheMaxSize*3-1)]; !ok {
+		t.Error("newest entry was evicted")
+	}
+	if _, ok := cache.entries["jti:0"]; ok {
+		t.Error("oldest entry was kept")
+	}
+}
@@ -0,0 +1,88 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"strings"
+	"unicode"
+)
+
+// creates function that splits text into lower case words
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// creates function that counts minimal number of single character edits needed to turn one word into another
+func LevenshteinDistance(a, b string) int {
+	// converts words to runes to handle non ascii letters
+	source, target := []rune(a), []rune(b)
+
+	// defines previous and current rows of distance matrix
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	// fills distance matrix row by row
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
+
+// creates function that returns how many typos are tolerated for a word of given length
+func maxTypos(word string) int {
+	switch length := len([]rune(word)); {
+	case length <= 3:
+		return 0
+	case length <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// creates function that scores how well query term matches any of given words, returns 0 if nothing matches
+func MatchTermScore(term string, words []string) float64 {
+	// defines best score
+	best := 0.0
+
+	for _, word := range words {
+		switch {
+		// exact word match
+		case word == term:
+			return 1
+		// word starts with query term
+		case strings.HasPrefix(word, term):
+			best = max(best, 0.75)
+		// word is close to query term or to its prefix(handles typos)
+		default:
+			allowed := maxTypos(term)
+			if allowed == 0 {
+				continue
+			}
+			distance := LevenshteinDistance(term, word)
+			// compares term with prefix of the word of the same length to match mistyped prefixes
+			if prefix := []rune(word); len(prefix) > len([]rune(term)) {
+				distance = min(distance, LevenshteinDistance(term, string(prefix[:len([]rune(term))])))
+			}
+			if distance <= allowed {
+				best = max(best, 0.5*(1-float64(distance)/float64(len([]rune(term)))))
+			}
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,97 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "The Matrix", want: []string{"the", "matrix"}},
+		{text: "  Spider-Man: No Way Home ", want: []string{"spider", "man", "no", "way", "home"}},
+		{text: "Blade Runner 2049", want: []string{"blade", "runner", "2049"}},
+		{text: "Amélie", want: []string{"amélie"}},
+		{text: "!!!", want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) && !(len(got) == 0 && len(tt.want) == 0) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "abc", b: "", want: 3},
+		{a: "", b: "abc", want: 3},
+		{a: "matrix", b: "matrix", want: 0},
+		{a: "matrx", b: "matrix", want: 1},
+		{a: "natrix", b: "matrix", want: 1},
+		{a: "matirx", b: "matrix", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "café", b: "cafe", want: 1},
+	}
+	for _, tt := range tests {
+		if got := LevenshteinDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("LevenshteinDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := LevenshteinDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("LevenshteinDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestMaxTypos(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{word: "up", want: 0},
+		{word: "cat", want: 0},
+		{word: "été", want: 0},
+		{word: "star", want: 1},
+		{word: "matrix", want: 1},
+		{word: "godfather", want: 2},
+	}
+	for _, tt := range tests {
+		if got := maxTypos(tt.word); got != tt.want {
+			t.Errorf("maxTypos(%q) = %d, want %d", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTermScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		term  string
+		words []string
+		want  float64
+	}{
+		{name: "exact word", term: "matrix", words: []string{"the", "matrix"}, want: 1},
+		{name: "prefix", term: "mat", words: []string{"the", "matrix"}, want: 0.75},
+		{name: "best of prefix and typo", term: "star", words: []string{"stars", "wars"}, want: 0.75},
+		{name: "missing letter", term: "matrx", words: []string{"matrix"}, want: 0.5 * (1 - 1.0/5)},
+		{name: "wrong first letter", term: "natrix", words: []string{"matrix"}, want: 0.5 * (1 - 1.0/6)},
+		{name: "two typos in long word", term: "godfathre", words: []string{"godfather"}, want: 0.5 * (1 - 2.0/9)},
+		{name: "mistyped prefix of longer word", term: "termi", words: []string{"terminator"}, want: 0.75},
+		{name: "mistyped prefix with typo", term: "termu", words: []string{"terminator"}, want: 0.5 * (1 - 1.0/5)},
+		{name: "too many typos", term: "matirx", words: []string{"matrix"}, want: 0},
+		{name: "no typos in short term", term: "cat", words: []string{"car"}, want: 0},
+		{name: "no words", term: "matrix", words: nil, want: 0},
+	}
+	for _, tt := range tests {
+		if got := MatchTermScore(tt.term, tt.words); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: MatchTermScore(%q, %q) = %v, want %v", tt.name, tt.term, tt.words, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// creates function that generates random url safe secret token, only its hash is stored so that leaked database does not leak tokens
+func GenerateSecretToken() (token string, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(bytes)
+	return token, HashSecretToken(token), nil
+}
+
+// creates function that hashes secret token, tokens are long and random so fast hash is enough
+func HashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,197 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defines errors of session refresh
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used, session has been revoked")
+	ErrSessionUserNotFound = errors.New("user of session not found")
+)
+
+// creates function that starts new session of user on device that made request and generates its tokens
+func CreateSession(ctx context.Context, client *mongo.Client, c *gin.Context, user models.User) (string, string, error) {
+	// defines session, its id is part of tokens
+	now := time.Now()
+	session := models.Session{
+		ID:         bson.NewObjectID(),
+		UserID:     user.UserID,
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+
+	// generates tokens
+	token, refreshToken, err := GenerateAllTokens(user.Email, user.FirstName, user.LastName, user.Role, user.UserID, session.ID.Hex())
+	if err != nil {
+		return "", "", err
+	}
+	session.RefreshTokenHash = HashSecretToken(refreshToken)
+
+	// stores session
+	if _, err := database.OpenCollection("sessions", client).InsertOne(ctx, session); err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+// creates function that swaps valid refresh token for new pair of tokens, using refresh token that was already swapped revokes whole session
+// because it means that token has been stolen
+func RotateSession(ctx context.Context, client *mongo.Client, claims *SignedDetails, refreshToken string) (string, string, error) {
+	sessionId, err := bson.ObjectIDFromHex(claims.SessionId)
+	if err != nil {
+		return "", "", ErrSessionNotFound
+	}
+	sessionCollection := database.OpenCollection("sessions", client)
+
+	// gets user, tokens carry current name and role of user
+	var user models.User
+	if err := database.OpenCollection("users", client).FindOne(ctx, bson.M{"user_id": claims.UserId}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", "", ErrSessionUserNotFound
+		}
+		return "", "", err
+	}
+
+	// generates new tokens
+	newToken, newRefreshToken, err := GenerateAllTokens(user.Email, user.FirstName, user.LastName, user.Role, user.UserID, claims.SessionId)
+	if err != nil {
+		return "", "", err
+	}
+
+	// swaps token hash only if presented token is current one, so that parallel requests can not both use it
+	now := time.Now()
+	filter := rotationFilter(sessionId, claims.UserId, refreshToken, now)
+	update := bson.M{
+		"$set": bson.M{
+			"refresh_token_hash": HashSecretToken(newRefreshToken),
+			"last_used_at":       now,
+			"expires_at":         now.Add(RefreshTokenTTL),
+		},
+		"$inc": bson.M{"rotations": 1},
+	}
+	result, err := sessionCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", "", err
+	}
+	if result.MatchedCount == 1 {
+		return newToken, newRefreshToken, nil
+	}
+
+	// finds out why token was not accepted
+	var session models.Session
+	err = sessionCollection.FindOne(ctx, bson.M{"_id": sessionId, "user_id": claims.UserId}).Decode(&session)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", "", ErrSessionNotFound
+		}
+		return "", "", err
+	}
+	rotationErr := rotationFailure(session, now)
+	if errors.Is(rotationErr, ErrRefreshTokenReused) {
+		if _, err := RevokeSessions(ctx, client, bson.M{"_id": sessionId}, models.SessionRevokedTokenReuse); err != nil {
+			return "", "", err
+		}
+	}
+	return "", "", rotationErr
+}
+
+// creates function that tells why refresh token of existing session was not accepted, token of active session that is not
+// its current one has been rotated already, so it is reused
+func rotationFailure(session models.Session, now time.Time) error {
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return ErrSessionRevoked
+	}
+	return ErrRefreshTokenReused
+}
+
+// creates function that builds filter of active session whose current refresh token is presented one
+func rotationFilter(sessionId bson.ObjectID, userId, refreshToken string, now time.Time) bson.M {
+	return bson.M{
+		"_id":                sessionId,
+		"user_id":            userId,
+		"refresh_token_hash": HashSecretToken(refreshToken),
+		"revoked_at":         bson.M{"$exists": false},
+		"expires_at":         bson.M{"$gt": now},
+	}
+}
+
+// creates function that revokes active sessions matching filter together with their access tokens and returns how many were revoked
+func RevokeSessions(ctx context.Context, client *mongo.Client, filter bson.M, reason string) (int64, error) {
+	sessionCollection := database.OpenCollection("sessions", client)
+
+	// finds active sessions matching filter
+	active := bson.M{"revoked_at": bson.M{"$exists": false}}
+	for key, value := range filter {
+		active[key] = value
+	}
+	var sessions []models.Session
+	cursor, err := sessionCollection.Find(ctx, active, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return 0, err
+	}
+	if len(sessions) == 0 {
+		return 0, nil
+	}
+	ids := make([]bson.ObjectID, len(sessions))
+	hexIds := make([]string, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+		hexIds[i] = session.ID.Hex()
+	}
+
+	// puts sessions on denylist first, so that their access tokens stop working even if marking sessions fails
+	if err := revokeSessionTokens(ctx, client, hexIds); err != nil {
+		return 0, err
+	}
+
+	// marks sessions as revoked, their refresh tokens can not be used any more
+	result, err := sessionCollection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// creates function that gets active sessions of user, most recently used first
+func GetActiveSessions(ctx context.Context, client *mongo.Client, userId string) ([]models.Session, error) {
+	cursor, err := database.OpenCollection("sessions", client).Find(ctx,
+		bson.M{"user_id": userId, "revoked_at": bson.M{"$exists": false}, "expires_at": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// creates function that gets session id from context
+func GetSessionIdFromContext(c *gin.Context) string {
+	sessionId, _ := c.Get("sessionId")
+	id, _ := sessionId.(string)
+	return id
+}
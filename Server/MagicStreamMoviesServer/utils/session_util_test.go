@@ -0,0 +1,67 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestRotationFilterMatchesOnlyCurrentRefreshToken(t *testing.T) {
+	sessionId := bson.NewObjectID()
+	now := time.Now()
+
+	// session stores hash of token it was rotated to last
+	session := bson.M{"refresh_token_hash": HashSecretToken("current-token")}
+
+	tests := []struct {
+		name      string
+		presented string
+		wantMatch bool
+	}{
+		{name: "current token", presented: "current-token", wantMatch: true},
+		{name: "token that was already rotated", presented: "previous-token", wantMatch: false},
+		{name: "empty token", presented: "", wantMatch: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := rotationFilter(sessionId, "user-1", tt.presented, now)
+			if filter["_id"] != sessionId || filter["user_id"] != "user-1" {
+				t.Fatalf("filter %v does not select session of user", filter)
+			}
+			if _, ok := filter["revoked_at"]; !ok {
+				t.Error("filter must skip revoked sessions")
+			}
+			if match := filter["refresh_token_hash"] == session["refresh_token_hash"]; match != tt.wantMatch {
+				t.Errorf("filter matches session = %v, want %v", match, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestRotationFailure(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		session models.Session
+		want    error
+	}{
+		{name: "active session with old token is reuse", session: models.Session{ExpiresAt: now.Add(time.Hour)}, want: ErrRefreshTokenReused},
+		{name: "revoked session", session: models.Session{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, want: ErrSessionRevoked},
+		{name: "expired session", session: models.Session{ExpiresAt: now.Add(-time.Second)}, want: ErrSessionRevoked},
+		{name: "session expiring now", session: models.Session{ExpiresAt: now}, want: ErrSessionRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := rotationFailure(tt.session, now); !errors.Is(err, tt.want) {
+				t.Errorf("rotationFailure = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// defines default signing key settings
+const (
+	defaultKeysDir = "keys"
+	// minRSAKeyBits is the smallest rsa key that is accepted
+	minRSAKeyBits = 2048
+)
+
+// defines SigningKey struct that holds one key of key set, private key is nil for keys that only verify tokens
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// defines KeySet struct that holds key that signs new tokens and all keys that tokens can be verified with
+type KeySet struct {
+	signing      *SigningKey
+	verification map[string]*SigningKey
+}
+
+// defines key set used by token functions, it is loaded once at startup
+var signingKeys *KeySet
+
+// creates function that loads key set from JWT_KEYS_DIR env and makes it used by token functions, key that signs tokens is picked with
+// JWT_SIGNING_KEY_ID env, it can be left empty when directory contains only one private key
+func InitSigningKeysFromEnv() error {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		dir = defaultKeysDir
+	}
+	keySet, err := LoadKeySet(dir, os.Getenv("JWT_SIGNING_KEY_ID"))
+	if err != nil {
+		return err
+	}
+	signingKeys = keySet
+	return nil
+}
+
+// creates function that loads every .pem file of directory as key, file name without extension is key id,
+// old keys can be kept as public keys so that tokens they signed stay valid after rotation
+func LoadKeySet(dir, signingKeyID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no signing keys found in %q, generate one with: go run ./cmd/genkey -dir %s", dir, dir)
+	}
+	sort.Strings(paths)
+
+	keySet := &KeySet{verification: map[string]*SigningKey{}}
+	var privateKeys []*SigningKey
+	for _, path := range paths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", path, err)
+		}
+		keySet.verification[key.ID] = key
+		if key.Private != nil {
+			privateKeys = append(privateKeys, key)
+		}
+	}
+
+	// picks key that signs new tokens
+	switch {
+	case signingKeyID != "":
+		key, ok := keySet.verification[signingKeyID]
+		if !ok || key.Private == nil {
+			return nil, fmt.Errorf("private key %q set by JWT_SIGNING_KEY_ID not found in %q", signingKeyID, dir)
+		}
+		keySet.signing = key
+	case len(privateKeys) == 1:
+		keySet.signing = privateKeys[0]
+	case len(privateKeys) == 0:
+		return nil, fmt.Errorf("no private key found in %q", dir)
+	default:
+		return nil, fmt.Errorf("%q contains several private keys, set JWT_SIGNING_KEY_ID to pick one", dir)
+	}
+
+	return keySet, nil
+}
+
+// creates function that reads pem file with rsa or ed25519 private or public key and refuses weak keys
+func loadKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("file is not pem encoded")
+	}
+
+	// parses key
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, only rsa and ed25519 keys are supported", parsed)
+	}
+
+	// refuses rsa keys that are too short
+	if rsaKey, ok := key.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("rsa key has %d bits, at least %d are required", rsaKey.N.BitLen(), minRSAKeyBits)
+	}
+	return key, nil
+}
+
+// creates method that signs claims with signing key and puts its id into kid header
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k == nil || k.signing == nil {
+		return "", errors.New("signing keys are not loaded")
+	}
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+	return token.SignedString(k.signing.Private)
+}
+
+// creates method that parses and verifies token with key named by its kid header
+func (k *KeySet) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	if k == nil {
+		return errors.New("signing keys are not loaded")
+	}
+	opts = append(opts,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := k.verification[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		// algorithm of token must be the one of key, so that token can not pick weaker way of verification
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("signing key %q does not use %s", kid, token.Method.Alg())
+		}
+		return key.Public, nil
+	}, opts...)
+	return err
+}
+
+// creates method that builds json web key set with public keys, clients use it to verify tokens
+func (k *KeySet) JWKS() map[string]any {
+	ids := make([]string, 0, len(k.verification))
+	for id := range k.verification {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	keys := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		key := k.verification[id]
+		jwk := map[string]string{"kid": key.ID, "use": "sig", "alg": key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+		}
+		keys = append(keys, jwk)
+	}
+	return map[string]any{"keys": keys}
+}
+
+// creates function that gets json web key set of loaded keys
+func GetJWKS() map[string]any {
+	if signingKeys == nil {
+		return map[string]any{"keys": []any{}}
+	}
+	return signingKeys.JWKS()
+}
@@ -0,0 +1,172 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// creates function that writes private key as pkcs8 pem file named by key id
+func writeTestKey(t *testing.T, dir, id string, key crypto.Signer) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey: %v", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+// creates function that generates ed25519 key
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+// creates function that generates rsa key of given size
+func newRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+// creates function that builds claims valid for one hour
+func testClaims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaKey := newRSAKey(t, 2048)
+	edKey := newEd25519Key(t)
+
+	tests := []struct {
+		name      string
+		keys      map[string]crypto.Signer
+		signingID string
+		wantErr   bool
+	}{
+		{name: "single ed25519 key", keys: map[string]crypto.Signer{"ed": edKey}},
+		{name: "single rsa key", keys: map[string]crypto.Signer{"rsa": rsaKey}},
+		{name: "several keys with signing id", keys: map[string]crypto.Signer{"ed": edKey, "rsa": rsaKey}, signingID: "rsa"},
+		{name: "several keys without signing id", keys: map[string]crypto.Signer{"ed": edKey, "rsa": rsaKey}, wantErr: true},
+		{name: "unknown signing id", keys: map[string]crypto.Signer{"ed": edKey}, signingID: "missing", wantErr: true},
+		{name: "weak rsa key", keys: map[string]crypto.Signer{"weak": newRSAKey(t, 1024)}, wantErr: true},
+		{name: "no keys", keys: map[string]crypto.Signer{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for id, key := range tt.keys {
+				writeTestKey(t, dir, id, key)
+			}
+			_, err := LoadKeySet(dir, tt.signingID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadKeySet error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetParse(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := newRSAKey(t, 2048)
+	edKey := newEd25519Key(t)
+	writeTestKey(t, dir, "rsa", rsaKey)
+	writeTestKey(t, dir, "ed", edKey)
+	keySet, err := LoadKeySet(dir, "ed")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+
+	// defines function that signs claims with given method and key and puts kid header
+	sign := func(method jwt.SigningMethod, kid string, claims jwt.Claims, key any) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return signed
+	}
+	valid, err := keySet.Sign(testClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	otherEdKey := newEd25519Key(t)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "token of signing key", token: valid},
+		{name: "token of other known key", token: sign(jwt.SigningMethodRS256, "rsa", testClaims(), rsaKey)},
+		{name: "unknown kid", token: sign(jwt.SigningMethodEdDSA, "unknown", testClaims(), edKey), wantErr: true},
+		{name: "missing kid", token: sign(jwt.SigningMethodEdDSA, "", testClaims(), edKey), wantErr: true},
+		{name: "alg of token differs from alg of key", token: sign(jwt.SigningMethodRS256, "ed", testClaims(), rsaKey), wantErr: true},
+		{name: "hmac token", token: sign(jwt.SigningMethodHS256, "ed", testClaims(), []byte("secret")), wantErr: true},
+		{name: "signed by other key with known kid", token: sign(jwt.SigningMethodEdDSA, "ed", testClaims(), otherEdKey), wantErr: true},
+		{name: "token without expiry", token: sign(jwt.SigningMethodEdDSA, "ed", jwt.RegisteredClaims{Subject: "user-1"}, edKey), wantErr: true},
+		{name: "expired token", token: sign(jwt.SigningMethodEdDSA, "ed",
+			jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}, edKey), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := keySet.Parse(tt.token, &jwt.RegisteredClaims{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAccessAndRefreshTokensAreNotInterchangeable(t *testing.T) {
+	dir := t.TempDir()
+	writeTestKey(t, dir, "ed", newEd25519Key(t))
+	keySet, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	previous := signingKeys
+	signingKeys = keySet
+	t.Cleanup(func() { signingKeys = previous })
+
+	token, refreshToken, err := GenerateAllTokens("user@example.com", "First", "Last", "USER", "user-1", "session-1")
+	if err != nil {
+		t.Fatalf("GenerateAllTokens: %v", err)
+	}
+	if _, err := ValidateToken(token); err != nil {
+		t.Errorf("ValidateToken(access token) error: %v", err)
+	}
+	if _, err := ValidateRefreshToken(refreshToken); err != nil {
+		t.Errorf("ValidateRefreshToken(refresh token) error: %v", err)
+	}
+	if _, err := ValidateToken(refreshToken); err == nil {
+		t.Error("refresh token was accepted as access token")
+	}
+	if _, err := ValidateRefreshToken(token); err == nil {
+		t.Error("access token was accepted as refresh token")
+	}
+}
@@ -3,16 +3,13 @@ package utils
 
 // imports packages
 import (
-	"context"
 	"errors"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
-	"github.com/valentynchystiakow/MagicStreamMovies/Server/MagicStreamMoviesServer/database"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 type SignedDetails struct {
@@ -21,17 +18,25 @@ type SignedDetails struct {
 	LastName  string
 	Role      string
 	UserId    string
+	// SessionId links token to session of device that user logged in from
+	SessionId string
 	jwt.RegisteredClaims
 }
 
-// defines secret key
-var SECRET_KEY string = os.Getenv("SECRET_KEY")
+// defines audiences of access and refresh tokens, they keep one kind of token from being accepted as the other
+const (
+	accessTokenAudience  = "access"
+	refreshTokenAudience = "refresh"
+)
+
+// defines how long access token lives
+const AccessTokenTTL = 24 * time.Hour
 
-// defines refresh secret key
-var SECRET_REFRESH_KEY string = os.Getenv("SECRET_REFRESH_KEY")
+// defines how long refresh token and session of device live
+const RefreshTokenTTL = 24 * 7 * time.Hour
 
 // creates function that generates all tokens
-func GenerateAllTokens(email, firstName, lastName, role, userId string) (string, string, error) {
+func GenerateAllTokens(email, firstName, lastName, role, userId, sessionId string) (string, string, error) {
 	// generates token
 	// defines claims that refer to signed details struct
 	claims := &SignedDetails{
@@ -40,18 +45,19 @@ func GenerateAllTokens(email, firstName, lastName, role, userId string) (string,
 		LastName:  lastName,
 		Role:      role,
 		UserId:    userId,
+		SessionId: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			// registered claims details
+			// registered claims details, id lets token be revoked before it expires
+			ID:        bson.NewObjectID().Hex(),
 			Issuer:    "MagicStream",
+			Audience:  jwt.ClaimStrings{accessTokenAudience},
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 		},
 	}
 
-	// defines token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// signs token with secret key
-	signedToken, err := token.SignedString([]byte(SECRET_KEY))
+	// signs token with signing key
+	signedToken, err := signingKeys.Sign(claims)
 
 	// if error occurs, returns empty strings and error
 	if err != nil {
@@ -66,18 +72,19 @@ func GenerateAllTokens(email, firstName, lastName, role, userId string) (string,
 		LastName:  lastName,
 		Role:      role,
 		UserId:    userId,
+		SessionId: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
-			// registered claims details
+			// registered claims details, id makes every rotated refresh token unique
+			ID:        bson.NewObjectID().Hex(),
 			Issuer:    "MagicStream",
+			Audience:  jwt.ClaimStrings{refreshTokenAudience},
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * 7 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 		},
 	}
 
-	// defines refresh token
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	// signs refresh token with secret key
-	signedRefreshToken, err := refreshToken.SignedString([]byte(SECRET_REFRESH_KEY))
+	// signs refresh token with signing key
+	signedRefreshToken, err := signingKeys.Sign(refreshClaims)
 
 	// if error occurs, returns empty strings and error
 	if err != nil {
@@ -89,49 +96,26 @@ func GenerateAllTokens(email, firstName, lastName, role, userId string) (string,
 
 }
 
-// creates function that updates all tokens in database
-func UpdateAllTokens(userId, token, refreshToken string, client *mongo.Client) (err error) {
-	// creates special context that cancels request if timeout occurs
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-	// cancels request when function ends(to prevent memory leaks)
-	defer cancel()
-
-	// defines time when token was updated
-	updateAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	// defines data to update
-	updateData := bson.M{"$set": bson.M{"token": token, "refresh_token": refreshToken, "update_at": updateAt}}
-
-	// opens user collection from database
-	var userCollection *mongo.Collection = database.OpenCollection("users", client)
-
-	// updates token data in database
-	_, err = userCollection.UpdateOne(ctx, bson.M{"user_id": userId}, updateData)
-
-	// if error occurs, returns error
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// creates function that gets access token
+// creates function that gets access token from Authorization header or access_token cookie
 func GetAccessToken(c *gin.Context) (string, error) {
-	// authHeader := c.Request.Header.Get("Authorization")
-	// if authHeader == "" {
-	// 	return "", errors.New("Authorization header is required")
-	// }
-	// tokenString := authHeader[len("Bearer "):]
-
-	// if tokenString == "" {
-	// 	return "", errors.New("Bearer token is required")
-	// }
+	// Authorization header takes precedence over cookie, so that client which sends header is never authenticated by stale cookie
+	if authHeader := c.GetHeader("Authorization"); authHeader != "" {
+		scheme, tokenString, found := strings.Cut(authHeader, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			return "", errors.New("authorization header must use Bearer scheme")
+		}
+		tokenString = strings.TrimSpace(tokenString)
+		if tokenString == "" {
+			return "", errors.New("bearer token is required")
+		}
+		return tokenString, nil
+	}
 
 	// gets access token from cookie
 	tokenString, err := c.Cookie("access_token")
 	if err != nil {
 
-		return "", err
+		return "", errors.New("access token is required in Authorization header or access_token cookie")
 	}
 
 	return tokenString, nil
@@ -144,25 +128,11 @@ func ValidateToken(tokenString string) (*SignedDetails, error) {
 	// defines claims that refer to signed details struct
 	claims := &SignedDetails{}
 
-	// defines token using ParseWithClaims function
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		// returns secret key as a byte slice type and error
-		return []byte(SECRET_KEY), nil
-	})
-	// if error occurs, returns empty claims and error
-	if err != nil {
-		return nil, err
-	}
-	// if token is of invalid type, returns empty claims and error
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	// parses token and verifies its signature, audience and expiry
+	if err := signingKeys.Parse(tokenString, claims, jwt.WithAudience(accessTokenAudience)); err != nil {
 		return nil, err
 	}
 
-	// checks if token is expired
-	if claims.ExpiresAt.Time.Before(time.Now()) {
-		return nil, errors.New("token has expired")
-	}
-
 	return claims, nil
 }
 
@@ -209,23 +179,10 @@ func ValidateRefreshToken(tokenString string) (*SignedDetails, error) {
 	// defines claims using SignedDetails struct
 	claims := &SignedDetails{}
 
-	// defines token
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-
-		return []byte(SECRET_REFRESH_KEY), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	// parses refresh token and verifies its signature, audience and expiry
+	if err := signingKeys.Parse(tokenString, claims, jwt.WithAudience(refreshTokenAudience)); err != nil {
 		return nil, err
 	}
 
-	if claims.ExpiresAt.Time.Before(time.Now()) {
-		return nil, errors.New("refresh token has expired")
-	}
-
 	return claims, nil
 }
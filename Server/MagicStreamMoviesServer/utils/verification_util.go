@@ -0,0 +1,43 @@
+// marks file as part of utils package
+package utils
+
+// imports packages
+import (
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// defines audience of email verification tokens, it keeps access tokens from being accepted as verification tokens and the other way round
+const emailVerificationAudience = "verify-email"
+
+// creates EmailVerificationClaims struct that holds user and email that verification link confirms
+type EmailVerificationClaims struct {
+	UserId string
+	Email  string
+	jwt.RegisteredClaims
+}
+
+// creates function that generates signed email verification token, token is tied to email so that link stops working once email changes
+func GenerateEmailVerificationToken(userId, email string, ttl time.Duration) (string, error) {
+	claims := &EmailVerificationClaims{
+		UserId: userId,
+		Email:  email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "MagicStream",
+			Audience:  jwt.ClaimStrings{emailVerificationAudience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+	return signingKeys.Sign(claims)
+}
+
+// creates function that validates email verification token
+func ValidateEmailVerificationToken(tokenString string) (*EmailVerificationClaims, error) {
+	claims := &EmailVerificationClaims{}
+	if err := signingKeys.Parse(tokenString, claims, jwt.WithAudience(emailVerificationAudience)); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}